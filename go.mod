@@ -13,3 +13,19 @@ require github.com/badoux/checkmail v1.2.1
 require golang.org/x/crypto v0.5.0
 
 require github.com/dgrijalva/jwt-go v3.2.0+incompatible
+
+require github.com/lib/pq v1.10.9
+
+require github.com/glebarez/go-sqlite v1.21.2
+
+require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.3.0 // indirect
+	github.com/mattn/go-isatty v0.0.17 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/sys v0.7.0 // indirect
+	modernc.org/libc v1.22.5 // indirect
+	modernc.org/mathutil v1.5.0 // indirect
+	modernc.org/memory v1.5.0 // indirect
+	modernc.org/sqlite v1.23.1 // indirect
+)
@@ -1,11 +1,23 @@
 package main
 
 import (
+	"api/src/authentication"
 	"api/src/config"
+	"api/src/controllers"
+	"api/src/database"
+	"api/src/mail"
+	"api/src/middlewares"
+	"api/src/repositories"
 	"api/src/router"
+	"api/src/security"
+	"api/src/throttling"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"time"
 )
 
 // We can use this function to generate a random secret key
@@ -25,10 +37,157 @@ func main() {
 	// Loading environment vars
 	config.Load()
 
+	// Connecting to the database (the connection pool is shared by all requests)
+	db, err := database.Connect()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	// Running the schema migrations subcommand (e.g. "api migrate up"), instead of the API
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err = migrate(db, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Making sure that the database schema is up to date, if required
+	if config.CheckSchemaVersion {
+		if err = checkSchemaVersion(db); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// Loading the keys used to sign and verify tokens
+	if err = authentication.LoadKeys(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Setting up the revoked tokens store
+	authentication.SetRevocationStore(newRevocationStore(db))
+
+	// Setting up the algorithm used to hash new passwords
+	security.SetHasher(newPasswordHasher())
+
+	// Setting up the key used to encrypt the two-factor authentication secrets
+	if err = security.SetTOTPEncryptionKey(config.TOTPEncryptionKey); err != nil {
+		log.Fatal(err)
+	}
+
+	// Setting up the policy applied to new passwords
+	security.SetPasswordPolicy(newPasswordPolicy())
+
+	// Making sure that the links sent by email can be followed
+	if err = checkAppURL(config.AppURL); err != nil {
+		log.Fatal(err)
+	}
+
+	// Creating the store which keeps the users and posts data
+	store := repositories.NewDatabaseStore(db)
+
+	// Purging the deleted users and posts after the retention period, in the background
+	go purgeDeleted(store.Users(), store.Posts(), config.DeletedRetention, config.PurgeInterval)
+
+	// Creating the request handlers, with the throttles for failed login attempts (by account and by client IP address)
+	handler := controllers.NewHandler(
+		db,
+		store,
+		repositories.NewPostsSearchRepository(db),
+		newMailer(),
+		newLoginThrottle(config.LoginFreeAttempts, config.LoginLockoutAttempts),
+		newLoginThrottle(config.LoginIPFreeAttempts, config.LoginIPLockoutAttempts),
+	)
+
 	// Creating the router
-	r := router.Generate()
+	r := router.Generate(handler, middlewares.New(db, store.Users()))
 
 	// Starting the server
 	fmt.Printf("Listening on port %d\n", config.Port)
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", config.Port), r))
 }
+
+// newRevocationStore creates the revoked tokens store defined on the configuration
+func newRevocationStore(db *database.DB) authentication.RevocationStore {
+	switch config.RevocationStore {
+	// "mysql" was the former name of the store on the database, whatever its driver
+	case "sql", "mysql":
+		return repositories.NewRevokedTokensRepository(db)
+	// Revoked tokens kept on memory are lost when the application restarts
+	case "memory":
+		return repositories.NewMemoryRevokedTokensRepository()
+	}
+	log.Fatalf("Unsupported revoked tokens store! %s", config.RevocationStore)
+	return nil
+}
+
+// newLoginThrottle creates a throttle for failed login attempts, with the configured lockout duration
+func newLoginThrottle(freeAttempts, lockoutAttempts int) *throttling.Throttle {
+	return throttling.NewThrottle(throttling.Policy{
+		FreeAttempts:    freeAttempts,
+		BaseDelay:       time.Second,
+		MaxDelay:        config.LoginLockoutDuration,
+		LockoutAttempts: lockoutAttempts,
+		LockoutDuration: config.LoginLockoutDuration,
+		Window:          config.LoginLockoutDuration,
+	}, throttling.SystemClock{})
+}
+
+// checkAppURL checks if the web app URL is absolute, since it's used on the links sent by email
+func checkAppURL(appURL string) error {
+	parsed, err := url.Parse(appURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("APP_URL must be set to the absolute URL of the web app (e.g. https://devbook.example.com)")
+	}
+	return nil
+}
+
+// newMailer creates the service used to send email messages, defined on the configuration
+func newMailer() mail.Mailer {
+	if config.Mailer == "smtp" {
+		return mail.NewSMTPMailer(config.SMTPHost, config.SMTPPort, config.SMTPUser, config.SMTPPass, config.MailFrom)
+	}
+
+	// Messages are written to the terminal, unless a file is provided
+	if config.MailLogFile == "" {
+		return mail.NewLogMailer(os.Stdout, config.MailFrom)
+	}
+	file, err := os.OpenFile(config.MailLogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return mail.NewLogMailer(file, config.MailFrom)
+}
+
+// newPasswordPolicy creates the policy applied to new passwords, defined on the configuration
+func newPasswordPolicy() security.PasswordPolicy {
+	policy := security.PasswordPolicy{MinLength: config.PasswordMinLength}
+	for _, class := range config.PasswordRequiredClasses {
+		if !security.IsValidPasswordClass(class) {
+			log.Fatalf("Invalid password character class! %s", class)
+		}
+		policy.RequiredClasses = append(policy.RequiredClasses, class)
+	}
+
+	// Breached passwords are only checked if their directory is provided
+	if config.BreachedPasswordsDir != "" {
+		policy.Breached = security.NewHashPrefixDirectory(config.BreachedPasswordsDir)
+	}
+	return policy
+}
+
+// newPasswordHasher creates the hasher used for new passwords, defined on the configuration
+func newPasswordHasher() security.Hasher {
+	switch config.PasswordHasher {
+	case "bcrypt":
+		return security.NewBcryptHasher(config.BcryptCost)
+	case "argon2id":
+		return security.NewArgon2idHasher(security.Argon2idParams{
+			Time:    uint32(config.Argon2Time),
+			Memory:  uint32(config.Argon2Memory),
+			Threads: uint8(config.Argon2Threads),
+		})
+	}
+	log.Fatalf("Unsupported password hasher! %s", config.PasswordHasher)
+	return nil
+}
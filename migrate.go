@@ -0,0 +1,81 @@
+package main
+
+import (
+	"api/src/config"
+	"api/src/database"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// migrate runs the "migrate" subcommand: "up" applies the pending migrations, "down [steps]" reverts
+// the last applied ones (one, by default) and "status" lists the migrations
+func migrate(db *database.DB, args []string) error {
+	if len(args) == 0 {
+		return errors.New("Usage: api migrate up|down [steps]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := db.MigrateUp(config.MigrationsLockTimeout)
+		for _, migration := range applied {
+			fmt.Printf("Applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("No pending migrations")
+		}
+		return err
+
+	case "down":
+		// Getting how many migrations must be reverted
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return errors.New("The number of steps must be a positive integer")
+			}
+		}
+
+		reverted, err := db.MigrateDown(steps, config.MigrationsLockTimeout)
+		for _, migration := range reverted {
+			fmt.Printf("Reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(reverted) == 0 {
+			fmt.Println("No applied migrations")
+		}
+		return err
+
+	case "status":
+		status, err := db.MigrationsStatus()
+		if err != nil {
+			return err
+		}
+		for _, migration := range status {
+			applied := "pending"
+			if migration.AppliedAt != nil {
+				applied = "applied at " + migration.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", migration.Version, migration.Name, applied)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("Unknown migrate command! %s", args[0])
+}
+
+// checkSchemaVersion makes sure that all migrations were applied to the database
+func checkSchemaVersion(db *database.DB) error {
+	version, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	latest, err := database.LatestVersion(db.Dialect())
+	if err != nil {
+		return err
+	}
+
+	if version < latest {
+		return fmt.Errorf("Database schema version is %d, but %d is required! Run \"api migrate up\"", version, latest)
+	}
+	return nil
+}
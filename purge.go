@@ -0,0 +1,42 @@
+package main
+
+import (
+	"api/src/repositories"
+	"context"
+	"log"
+	"time"
+)
+
+// purgeDeleted permanently removes, on every interval, the users and posts deleted before the retention period
+// It runs until the application stops, so it must be started on its own goroutine
+func purgeDeleted(users repositories.UserStore, posts repositories.PostStore, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purge(users, posts, time.Now().Add(-retention))
+		<-ticker.C
+	}
+}
+
+// purge removes the users and posts deleted before the provided time, logging how many were removed
+// Failures are only logged, since the purge is tried again on the next interval
+func purge(users repositories.UserStore, posts repositories.PostStore, deletedBefore time.Time) {
+	ctx := context.Background()
+
+	// Removing the users (their posts are removed along with them)
+	purgedUsers, err := users.Purge(ctx, deletedBefore)
+	if err != nil {
+		log.Printf("Error purging deleted users: %v", err)
+	}
+
+	// Removing the posts deleted on their own
+	purgedPosts, err := posts.Purge(ctx, deletedBefore)
+	if err != nil {
+		log.Printf("Error purging deleted posts: %v", err)
+	}
+
+	if purgedUsers > 0 || purgedPosts > 0 {
+		log.Printf("Purged %d deleted users and %d deleted posts", purgedUsers, purgedPosts)
+	}
+}
@@ -0,0 +1,75 @@
+package authentication
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// Claims represents the data carried by the access tokens
+type Claims struct {
+	UserID    uint64   `json:"userId"`
+	Roles     []string `json:"roles,omitempty"`
+	SessionID string   `json:"sid,omitempty"`
+	// Must match the user's current token version, otherwise the token is no longer accepted
+	TokenVersion uint64 `json:"ver"`
+	// Tokens issued for other purposes (e.g. two-factor challenges) are not accepted as access tokens
+	Purpose string `json:"purpose,omitempty"`
+	// Email address being verified (only on email verification tokens)
+	Email string `json:"email,omitempty"`
+	// Set only when authenticated with a personal access token (never part of JWTs)
+	PersonalAccessTokenID uint64   `json:"-"`
+	Scopes                []string `json:"-"`
+	// Standard claims: token ID (jti), issue time (iat) and expiration time (exp)
+	jwt.StandardClaims
+}
+
+// Valid checks if the claims are valid (used by the jwt package while parsing tokens)
+func (claims Claims) Valid() error {
+	// Checking the expiration time
+	if err := claims.StandardClaims.Valid(); err != nil {
+		return err
+	}
+
+	// Tokens must have an ID, so they can be revoked, and belong to an user
+	if claims.Id == "" || claims.UserID == 0 {
+		return errors.New("Invalid token")
+	}
+
+	return nil
+}
+
+// claimsKey is the key used to store the claims on the request context
+type claimsKey struct{}
+
+// WithClaims returns a copy of the context carrying the token claims
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext returns the token claims stored on the context, if any
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
+
+// ExtractClaims returns the claims from the token provided on the request
+// The token must have already been validated by the authentication middleware
+func ExtractClaims(r *http.Request) (*Claims, error) {
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		return nil, errors.New("Request is not authenticated")
+	}
+	return claims, nil
+}
+
+// ExtractUserID returns the user ID present on the token
+func ExtractUserID(r *http.Request) (uint64, error) {
+	claims, err := ExtractClaims(r)
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserID, nil
+}
@@ -0,0 +1,58 @@
+package authentication
+
+import (
+	"crypto/ed25519"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// signingMethodEdDSA implements the EdDSA (Ed25519) signing method, not provided by the jwt package
+type signingMethodEdDSA struct{}
+
+// SigningMethodEdDSA is the signing method used for Ed25519 keys
+var SigningMethodEdDSA = &signingMethodEdDSA{}
+
+func init() {
+	// Registering the signing method, so tokens using it can be parsed
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+// Alg returns the name of the signing method
+func (method *signingMethodEdDSA) Alg() string {
+	return "EdDSA"
+}
+
+// Verify checks if the signature is valid for the signing string, using an Ed25519 public key
+func (method *signingMethodEdDSA) Verify(signingString, signature string, key interface{}) error {
+	// Checking the key type
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return jwt.ErrInvalidKeyType
+	}
+
+	// Decoding the signature
+	signatureBytes, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	// Verifying the signature
+	if !ed25519.Verify(publicKey, []byte(signingString), signatureBytes) {
+		return jwt.ErrSignatureInvalid
+	}
+	return nil
+}
+
+// Sign creates the signature for the signing string, using an Ed25519 private key
+func (method *signingMethodEdDSA) Sign(signingString string, key interface{}) (string, error) {
+	// Checking the key type
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	// Signing and encoding the signature
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
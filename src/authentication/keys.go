@@ -0,0 +1,220 @@
+package authentication
+
+import (
+	"api/src/config"
+	"api/src/models"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"sort"
+	"strings"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// signingKey represents a key used to sign or verify tokens, along with its ID (kid) and signing method
+type signingKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	Key    interface{}
+}
+
+// Defining the keys used by the application
+var (
+	// Key used to sign new tokens
+	currentSigningKey *signingKey
+	// Keys accepted while verifying tokens, by their IDs
+	verificationKeys = map[string]signingKey{}
+)
+
+// LoadKeys loads the keys used to sign and verify tokens, according to the configuration
+func LoadKeys() error {
+	// Symmetric keys: the secret is used both to sign and verify tokens
+	if config.JWTAlgorithm == jwt.SigningMethodHS256.Alg() {
+		if len(config.SecretKey) == 0 {
+			return errors.New("SECRET_KEY must be set to sign tokens with HS256")
+		}
+		currentSigningKey = &signingKey{Method: jwt.SigningMethodHS256, Key: config.SecretKey}
+		verificationKeys = map[string]signingKey{"": *currentSigningKey}
+		return nil
+	}
+
+	// Asymmetric keys: reading the private key used to sign tokens
+	privateKey, err := readKeyFile(config.JWTSigningKeyFile)
+	if err != nil {
+		return err
+	}
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return errors.New("Signing key file must contain a private key")
+	}
+	key, err := newSigningKey(config.JWTSigningKeyID, privateKey)
+	if err != nil {
+		return err
+	}
+	if key.Method.Alg() != config.JWTAlgorithm {
+		return fmt.Errorf("Signing key can't be used with the %s algorithm", config.JWTAlgorithm)
+	}
+
+	// The public key from the signing key is always accepted
+	keys := map[string]signingKey{}
+	publicKey := key
+	publicKey.Key = signer.Public()
+	keys[key.ID] = publicKey
+
+	// Reading other accepted keys (e.g. keys being rotated out), provided as "kid=path" or "path"
+	for _, item := range config.JWTVerificationKeyFiles {
+		keyID, path := "", item
+		if parts := strings.SplitN(item, "=", 2); len(parts) == 2 {
+			keyID, path = parts[0], parts[1]
+		}
+
+		// Private keys may be provided as well, but only their public part is kept
+		verificationKey, err := readKeyFile(path)
+		if err != nil {
+			return err
+		}
+		if signer, ok := verificationKey.(crypto.Signer); ok {
+			verificationKey = signer.Public()
+		}
+
+		key, err := newSigningKey(keyID, verificationKey)
+		if err != nil {
+			return err
+		}
+		if _, exists := keys[key.ID]; exists {
+			return fmt.Errorf("Duplicated key ID! %s", key.ID)
+		}
+		keys[key.ID] = key
+	}
+
+	// Replacing the current keys
+	currentSigningKey = &key
+	verificationKeys = keys
+	return nil
+}
+
+// JWKS returns the public keys accepted while verifying tokens, as a JSON web key set
+// Symmetric keys are never returned, since they're secret
+func JWKS() models.JSONWebKeySet {
+	keySet := models.JSONWebKeySet{Keys: []models.JSONWebKey{}}
+	for _, key := range verificationKeys {
+		if jwk, ok := toJSONWebKey(key); ok {
+			keySet.Keys = append(keySet.Keys, jwk)
+		}
+	}
+
+	// Sorting keys by their IDs, so the response is stable
+	sort.Slice(keySet.Keys, func(i, j int) bool {
+		return keySet.Keys[i].KeyID < keySet.Keys[j].KeyID
+	})
+	return keySet
+}
+
+// newSigningKey creates a signing key for a RSA or Ed25519 key (public or private)
+// If no key ID is provided, the key thumbprint (RFC 7638) is used as its ID
+func newSigningKey(keyID string, key interface{}) (signingKey, error) {
+	// Getting the signing method according to the key type
+	var method jwt.SigningMethod
+	switch key.(type) {
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		method = jwt.SigningMethodRS256
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		method = SigningMethodEdDSA
+	default:
+		return signingKey{}, fmt.Errorf("Unsupported key type! %T", key)
+	}
+	newKey := signingKey{ID: keyID, Method: method, Key: key}
+
+	// Generating the key ID from its public part
+	if newKey.ID == "" {
+		public := newKey
+		if signer, ok := key.(crypto.Signer); ok {
+			public.Key = signer.Public()
+		}
+		jwk, _ := toJSONWebKey(public)
+		newKey.ID = thumbprint(jwk)
+	}
+
+	return newKey, nil
+}
+
+// readKeyFile reads a PEM encoded key (RSA or Ed25519, public or private) from a file
+func readKeyFile(path string) (interface{}, error) {
+	if path == "" {
+		return nil, errors.New("A key file must be provided to sign tokens with asymmetric algorithms")
+	}
+
+	// Reading the file data
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("Invalid PEM data on key file! %s", path)
+	}
+
+	// Parsing the key according to the PEM block type
+	switch block.Type {
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	return nil, fmt.Errorf("Unsupported PEM block type on key file! %s", block.Type)
+}
+
+// toJSONWebKey formats a public key as a JSON web key
+func toJSONWebKey(key signingKey) (models.JSONWebKey, bool) {
+	jwk := models.JSONWebKey{KeyID: key.ID, Use: "sig", Algorithm: key.Method.Alg()}
+	switch publicKey := key.Key.(type) {
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.Modulus = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+		jwk.Exponent = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+	default:
+		return models.JSONWebKey{}, false
+	}
+	return jwk, true
+}
+
+// thumbprint calculates a JSON web key thumbprint (RFC 7638)
+func thumbprint(jwk models.JSONWebKey) string {
+	// Only the required members are used, in lexicographic order
+	var members interface{}
+	if jwk.KeyType == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.Exponent, jwk.KeyType, jwk.Modulus}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X}
+	}
+
+	data, _ := json.Marshal(members)
+	hash := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
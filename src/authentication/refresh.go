@@ -0,0 +1,58 @@
+package authentication
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// CreateRefreshToken generates a new random refresh token, returning it along with its hash
+// Only the hash must be stored on the database, the token itself is sent to the user
+func CreateRefreshToken() (string, string, error) {
+	// Generating the random token
+	token, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Returning the token and its hash
+	return token, HashToken(token), nil
+}
+
+// CreatePasswordResetToken generates a new random password reset token, returning it along with its hash
+// Only the hash must be stored on the database, the token itself is sent to the user by email
+func CreatePasswordResetToken() (string, string, error) {
+	// Generating the random token
+	token, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Returning the token and its hash
+	return token, HashToken(token), nil
+}
+
+// CreateFamilyID generates a new ID for a refresh tokens family (all tokens rotated from the same login)
+func CreateFamilyID() (string, error) {
+	return randomString(16)
+}
+
+// HashToken returns the hash used to store opaque tokens on the database
+func HashToken(token string) string {
+	// Since tokens are random and long, a fast hash function is enough here
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// randomString generates an URL safe random string from the provided number of bytes
+func randomString(size int) (string, error) {
+	// Generating random data
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	// Encoding data so it can be sent on requests
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
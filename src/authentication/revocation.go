@@ -0,0 +1,55 @@
+package authentication
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RevocationStore represents a storage for revoked tokens
+type RevocationStore interface {
+	// Revoke invalidates a single token (by its ID) until it expires
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	// RevokeAllByUser invalidates all tokens issued to an user before the provided time
+	RevokeAllByUser(ctx context.Context, userID uint64, issuedBefore time.Time) error
+	// IsRevoked checks if a token issued to an user was revoked
+	IsRevoked(ctx context.Context, tokenID string, userID uint64, issuedAt time.Time) (bool, error)
+}
+
+// revocationStore is the store used to revoke and check revoked tokens
+var revocationStore RevocationStore
+
+// SetRevocationStore defines the store used to revoke and check revoked tokens
+func SetRevocationStore(store RevocationStore) {
+	revocationStore = store
+}
+
+// RevokeToken invalidates a single token, by its claims
+func RevokeToken(ctx context.Context, claims *Claims) error {
+	if revocationStore == nil {
+		return errors.New("Revocation store is not configured")
+	}
+
+	// Revoking the token on the store
+	return revocationStore.Revoke(ctx, claims.Id, time.Unix(claims.ExpiresAt, 0))
+}
+
+// RevokeUserTokens invalidates all tokens issued to an user until now
+func RevokeUserTokens(ctx context.Context, userID uint64) error {
+	if revocationStore == nil {
+		return errors.New("Revocation store is not configured")
+	}
+
+	// Revoking the user tokens on the store
+	return revocationStore.RevokeAllByUser(ctx, userID, time.Now())
+}
+
+// IsRevoked checks if a token was revoked, by its claims
+func IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	if revocationStore == nil {
+		return false, errors.New("Revocation store is not configured")
+	}
+
+	// Checking the token on the store
+	return revocationStore.IsRevoked(ctx, claims.Id, claims.UserID, time.Unix(claims.IssuedAt, 0))
+}
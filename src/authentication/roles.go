@@ -0,0 +1,53 @@
+package authentication
+
+// Defining the roles an user may have
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Defining the permissions granted by the roles
+const (
+	// Allows editing and deleting other users' posts
+	PermissionModeratePosts = "posts:moderate"
+	// Allows managing other users' accounts (roles, tokens and deletion)
+	PermissionManageUsers = "users:manage"
+)
+
+// rolesPermissions defines the permissions granted by each role
+var rolesPermissions = map[string][]string{
+	RoleUser:      {},
+	RoleModerator: {PermissionModeratePosts},
+	RoleAdmin:     {PermissionModeratePosts, PermissionManageUsers},
+}
+
+// IsValidRole checks if a role exists
+func IsValidRole(role string) bool {
+	_, ok := rolesPermissions[role]
+	return ok
+}
+
+// HasAnyRole checks if the claims carry at least one of the provided roles
+func (claims *Claims) HasAnyRole(roles ...string) bool {
+	for _, role := range roles {
+		for _, claimsRole := range claims.Roles {
+			if role == claimsRole {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasPermission checks if any of the claims roles grants the provided permission
+func (claims *Claims) HasPermission(permission string) bool {
+	for _, role := range claims.Roles {
+		for _, rolePermission := range rolesPermissions[role] {
+			if permission == rolePermission {
+				return true
+			}
+		}
+	}
+	return false
+}
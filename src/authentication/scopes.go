@@ -0,0 +1,78 @@
+package authentication
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Defining the scopes which may be granted to personal access tokens
+const (
+	ScopePostsRead  = "posts:read"
+	ScopePostsWrite = "posts:write"
+	ScopeUsersRead  = "users:read"
+	ScopeUsersWrite = "users:write"
+	ScopeFollow     = "follow"
+)
+
+// PersonalAccessTokenPrefix identifies personal access tokens, so they're not parsed as JWTs
+const PersonalAccessTokenPrefix = "dbp_"
+
+// scopes lists all existing scopes
+var scopes = []string{ScopePostsRead, ScopePostsWrite, ScopeUsersRead, ScopeUsersWrite, ScopeFollow}
+
+// IsValidScope checks if a scope exists
+func IsValidScope(scope string) bool {
+	for _, existingScope := range scopes {
+		if scope == existingScope {
+			return true
+		}
+	}
+	return false
+}
+
+// CreatePersonalAccessToken generates a new random personal access token, returning it along with its hash
+// Only the hash must be stored on the database, the token itself is shown to the user just once
+func CreatePersonalAccessToken() (string, string, error) {
+	// Generating the random token
+	token, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	token = PersonalAccessTokenPrefix + token
+
+	// Returning the token and its hash
+	return token, HashToken(token), nil
+}
+
+// ExtractPersonalAccessToken returns the personal access token provided on the request, if any
+func ExtractPersonalAccessToken(r *http.Request) (string, bool) {
+	token := extractToken(r)
+	return token, strings.HasPrefix(token, PersonalAccessTokenPrefix)
+}
+
+// IsPersonalAccessToken checks if the claims were obtained from a personal access token
+func (claims *Claims) IsPersonalAccessToken() bool {
+	return claims.PersonalAccessTokenID != 0
+}
+
+// HasScopes checks if the claims allow all the provided scopes
+// Tokens issued on login have full access, only personal access tokens are limited by scopes
+func (claims *Claims) HasScopes(requiredScopes ...string) bool {
+	if !claims.IsPersonalAccessToken() {
+		return true
+	}
+
+	for _, requiredScope := range requiredScopes {
+		granted := false
+		for _, scope := range claims.Scopes {
+			if scope == requiredScope {
+				granted = true
+				break
+			}
+		}
+		if !granted {
+			return false
+		}
+	}
+	return true
+}
@@ -6,68 +6,89 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 )
 
+// Defining the purposes of tokens which are not access tokens
+const (
+	// Issued after the password is checked, exchanged for an access token with a two-factor code
+	PurposeTwoFactorChallenge = "2fa-challenge"
+	// Sent by email to confirm the user owns the email address
+	PurposeEmailVerification = "email-verification"
+)
+
 // CreateToken generates a JSON web token for the user with defined permissions
-func CreateToken(userID uint64) (string, error) {
-	// Setting user token permissions
-	permissions := jwt.MapClaims{}
-	permissions["authorized"] = true
-	// Setting the token duration
-	permissions["exp"] = time.Now().Add(time.Hour * 6).Unix()
-	permissions["userId"] = userID
-	// Creating the token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, permissions)
-	// Signing the token and returning it
-	// The secret must be generated in a safe way and stored on the .env file
-	return token.SignedString([]byte(config.SecretKey))
+// The claims must have the user ID and token version set, the other standard claims are filled here
+func CreateToken(claims Claims) (string, error) {
+	// Access tokens are short-lived, refresh tokens are used to renew them
+	claims.Purpose = ""
+	return createSignedToken(claims, config.AccessTokenDuration)
 }
 
-// ValidateToken checks if token provided on the request is valid
-func ValidateToken(r *http.Request) error {
-	// Getting the token string
-	tokenString := extractToken(r)
-	// Parsing the token string
-	token, err := jwt.Parse(tokenString, returnVerificationKey)
+// CreatePurposeToken generates a short-lived JSON web token for a specific purpose (not accepted as access token)
+// The claims must have the user ID set, as well as the data required by the purpose
+func CreatePurposeToken(claims Claims, purpose string, duration time.Duration) (string, error) {
+	claims.Purpose = purpose
+	return createSignedToken(claims, duration)
+}
+
+// createSignedToken fills the standard claims and signs the token
+func createSignedToken(claims Claims, duration time.Duration) (string, error) {
+	// Checking if the signing keys were loaded
+	if currentSigningKey == nil {
+		return "", errors.New("Signing keys were not loaded")
+	}
+
+	// Generating the token ID, so it can be revoked later
+	tokenID, err := randomString(16)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	// Checking if required claims are present on token and this is valid
-	if _, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return nil
+	// Setting the token ID and duration
+	now := time.Now()
+	claims.Id = tokenID
+	claims.IssuedAt = now.Unix()
+	claims.ExpiresAt = now.Add(duration).Unix()
+	// Creating the token
+	token := jwt.NewWithClaims(currentSigningKey.Method, claims)
+	// Setting the key ID, so the right key is used to verify the token (secret keys have no ID)
+	if currentSigningKey.ID != "" {
+		token.Header["kid"] = currentSigningKey.ID
 	}
+	// Signing the token and returning it
+	// The keys must be generated in a safe way and their paths (or the secret) stored on the .env file
+	return token.SignedString(currentSigningKey.Key)
+}
 
-	// Otherwise, we'll return the error
-	return errors.New("Invalid token")
+// ParseToken parses and validates the access token provided on the request, returning its claims
+func ParseToken(r *http.Request) (*Claims, error) {
+	return parseSignedToken(extractToken(r), "")
 }
 
-// ExtractUserID returns the user ID present on the token
-func ExtractUserID(r *http.Request) (uint64, error) {
-	// Getting the token string
-	tokenString := extractToken(r)
+// ParsePurposeToken parses and validates a token issued for a specific purpose, returning its claims
+func ParsePurposeToken(tokenString, purpose string) (*Claims, error) {
+	return parseSignedToken(tokenString, purpose)
+}
+
+// parseSignedToken parses and validates a token, checking if it was issued for the expected purpose
+func parseSignedToken(tokenString, purpose string) (*Claims, error) {
 	// Parsing the token string
-	token, err := jwt.Parse(tokenString, returnVerificationKey)
+	var claims Claims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, returnVerificationKey)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	// Checking if required claims, valid token and getting permissions from token
-	if permissions, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userId, err := strconv.ParseUint(fmt.Sprintf("%.0f", permissions["userId"]), 10, 64)
-		if err != nil {
-			return 0, err
-		}
-		return userId, nil
+	// Checking if the token is valid and was issued for the expected purpose
+	if !token.Valid || claims.Purpose != purpose {
+		return nil, errors.New("Invalid token")
 	}
 
-	// Otherwise, we'll return the error
-	return 0, errors.New("Invalid token")
+	return &claims, nil
 }
 
 // extractToken gets the token provided on the request headers
@@ -87,11 +108,18 @@ func extractToken(r *http.Request) string {
 
 // returnVerificationKey will return the token's verification key
 func returnVerificationKey(token *jwt.Token) (interface{}, error) {
-	// Checking signing method consistency
-	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+	// Getting the key by the ID provided on the token
+	keyID, _ := token.Header["kid"].(string)
+	key, ok := verificationKeys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("Unknown signing key! %v", keyID)
+	}
+
+	// Checking signing method consistency (the algorithm must match the key's one)
+	if token.Method.Alg() != key.Method.Alg() {
 		return nil, fmt.Errorf("Unexpected signing method! %v", token.Header["alg"])
 	}
 
 	// Returning the token's verification key
-	return config.SecretKey, nil
+	return key.Key, nil
 }
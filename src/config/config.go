@@ -3,20 +3,94 @@ package config
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// SQLiteConnParams are the parameters of the SQLite connections: foreign keys must be enabled on each connection,
+// and writers wait for locks instead of failing. Transactions take the write lock when they start, so they're
+// serialized like locked rows
+const SQLiteConnParams = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_time_format=sqlite&_txlock=immediate"
+
 // Defining vars to be used by the application
 var (
-	// Connection string with MySQL
+	// Database driver ("mysql", "postgres" or "sqlite") and its connection string
+	DbDriver     = "mysql"
 	DbConnString = ""
 	// Port number where API will be running
 	Port = 0
-	// Secret key for JWT signing
+	// Secret key for JWT signing (when using HS256)
 	SecretKey []byte
+	// Algorithm used for JWT signing ("HS256", "RS256" or "EdDSA")
+	JWTAlgorithm = "HS256"
+	// Private key file (and its ID) for JWT signing (when using RS256 or EdDSA)
+	JWTSigningKeyFile = ""
+	JWTSigningKeyID   = ""
+	// Other public key files accepted for JWT verification (e.g. during keys rotation), as "kid=path" or "path"
+	JWTVerificationKeyFiles []string
+	// Lifetime of the access tokens (JWT)
+	AccessTokenDuration = 15 * time.Minute
+	// Lifetime of the refresh tokens
+	RefreshTokenDuration = 30 * 24 * time.Hour
+	// Where revoked tokens are stored ("sql", which uses the configured database, or "memory")
+	RevocationStore = "sql"
+	// Issuer name displayed on authenticator apps (two-factor authentication)
+	TOTPIssuer = "DevBook"
+	// Key used to encrypt the TOTP secrets stored on the database
+	TOTPEncryptionKey []byte
+	// Failed login attempts allowed (per account and per IP address) before attempts are delayed
+	LoginFreeAttempts   = 3
+	LoginIPFreeAttempts = 20
+	// Failed login attempts (per account and per IP address) which lock further attempts, and for how long
+	LoginLockoutAttempts   = 10
+	LoginIPLockoutAttempts = 100
+	LoginLockoutDuration   = 15 * time.Minute
+	// Trust the client IP address provided by a reverse proxy (X-Forwarded-For/X-Real-IP headers)
+	TrustProxyHeaders = false
+	// Base URL of the web app (which must be absolute), used on links sent by email
+	AppURL = ""
+	// How email messages are sent ("smtp" or "log", which writes them to MailLogFile or the terminal)
+	Mailer      = "log"
+	MailLogFile = ""
+	// Sender address and SMTP server settings
+	MailFrom = ""
+	SMTPHost = ""
+	SMTPPort = 587
+	SMTPUser = ""
+	SMTPPass = ""
+	// Lifetime of the email verification links
+	EmailVerificationDuration = 24 * time.Hour
+	// Lifetime of the password reset links
+	PasswordResetDuration = time.Hour
+	// Password policy: minimum length, required character classes (lower, upper, digit, symbol)
+	PasswordMinLength       = 8
+	PasswordRequiredClasses []string
+	// Directory with the breached passwords SHA-1 hash ranges (no check is done if it's empty)
+	BreachedPasswordsDir = ""
+	// Algorithm used to hash new passwords ("bcrypt" or "argon2id") and its cost parameters
+	PasswordHasher = "bcrypt"
+	BcryptCost     = 10
+	Argon2Time     = 2
+	Argon2Memory   = 19 * 1024
+	Argon2Threads  = 1
+	// Database connection pool settings
+	DbMaxOpenConns    = 25
+	DbMaxIdleConns    = 25
+	DbConnMaxLifetime = 5 * time.Minute
+	DbConnMaxIdleTime = 5 * time.Minute
+	// Default timeout of each database query (queries are also cancelled when the request is)
+	DbQueryTimeout = 5 * time.Second
+	// How long migration runners wait for each other, and whether the schema version is checked on startup
+	MigrationsLockTimeout = time.Minute
+	CheckSchemaVersion    = false
+	// How long deleted users and posts are kept (so they can be restored), and how often the expired ones are purged
+	DeletedRetention = 30 * 24 * time.Hour
+	PurgeInterval    = time.Hour
 )
 
 // Load initializes environment variables
@@ -36,13 +110,162 @@ func Load() {
 		Port = 9000
 	}
 
-	// Creating database connection string
-	DbConnString = fmt.Sprintf("%s:%s@/%s?charset=utf8&parseTime=True&loc=Local",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASS"),
-		os.Getenv("DB_NAME"),
-	)
+	// Setting the database driver
+	DbDriver = os.Getenv("DB_DRIVER")
+	if DbDriver == "" {
+		// Default database driver
+		DbDriver = "mysql"
+	}
+
+	// Creating database connection string, according to the driver
+	switch DbDriver {
+	case "postgres":
+		DbConnString = fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=%s",
+			url.QueryEscape(os.Getenv("DB_USER")),
+			url.QueryEscape(os.Getenv("DB_PASS")),
+			stringFromEnv("DB_HOST", "localhost:5432"),
+			os.Getenv("DB_NAME"),
+			stringFromEnv("DB_SSLMODE", "disable"),
+		)
+	case "sqlite":
+		DbConnString = fmt.Sprintf("file:%s?%s", stringFromEnv("DB_PATH", "devbook.db"), SQLiteConnParams)
+	default:
+		address := ""
+		if host := os.Getenv("DB_HOST"); host != "" {
+			address = fmt.Sprintf("tcp(%s)", host)
+		}
+		DbConnString = fmt.Sprintf("%s:%s@%s/%s?charset=utf8&parseTime=True&loc=Local",
+			os.Getenv("DB_USER"),
+			os.Getenv("DB_PASS"),
+			address,
+			os.Getenv("DB_NAME"),
+		)
+	}
+
+	// Setting the database connection pool settings
+	DbMaxOpenConns = intFromEnv("DB_MAX_OPEN_CONNS", 25)
+	DbMaxIdleConns = intFromEnv("DB_MAX_IDLE_CONNS", 25)
+	DbConnMaxLifetime = durationFromEnv("DB_CONN_MAX_LIFETIME", 5*time.Minute)
+	DbConnMaxIdleTime = durationFromEnv("DB_CONN_MAX_IDLE_TIME", 5*time.Minute)
+	DbQueryTimeout = durationFromEnv("DB_QUERY_TIMEOUT", 5*time.Second)
+
+	// Setting the schema migrations settings
+	MigrationsLockTimeout = durationFromEnv("MIGRATIONS_LOCK_TIMEOUT", time.Minute)
+	CheckSchemaVersion = os.Getenv("CHECK_SCHEMA_VERSION") == "true"
+
+	// Setting the deleted data retention settings
+	DeletedRetention = durationFromEnv("DELETED_RETENTION", 30*24*time.Hour)
+	PurgeInterval = durationFromEnv("PURGE_INTERVAL", time.Hour)
 
 	// Setting the secret key
 	SecretKey = []byte(os.Getenv("SECRET_KEY"))
+
+	// Setting the JWT signing algorithm and keys
+	JWTAlgorithm = os.Getenv("JWT_ALGORITHM")
+	if JWTAlgorithm == "" {
+		// Default JWT signing algorithm
+		JWTAlgorithm = "HS256"
+	}
+	JWTSigningKeyFile = os.Getenv("JWT_SIGNING_KEY_FILE")
+	JWTSigningKeyID = os.Getenv("JWT_SIGNING_KEY_ID")
+	JWTVerificationKeyFiles = nil
+	for _, item := range strings.Split(os.Getenv("JWT_VERIFICATION_KEY_FILES"), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			JWTVerificationKeyFiles = append(JWTVerificationKeyFiles, item)
+		}
+	}
+
+	// Tokens durations must be converted to time.Duration (e.g. "15m", "720h")
+	AccessTokenDuration = durationFromEnv("ACCESS_TOKEN_DURATION", 15*time.Minute)
+	RefreshTokenDuration = durationFromEnv("REFRESH_TOKEN_DURATION", 30*24*time.Hour)
+
+	// Setting the revoked tokens store
+	RevocationStore = os.Getenv("REVOCATION_STORE")
+	if RevocationStore == "" {
+		// Default revoked tokens store
+		RevocationStore = "sql"
+	}
+
+	// Setting the two-factor authentication issuer name
+	TOTPIssuer = os.Getenv("TOTP_ISSUER")
+	if TOTPIssuer == "" {
+		// Default issuer name
+		TOTPIssuer = "DevBook"
+	}
+	TOTPEncryptionKey = []byte(os.Getenv("TOTP_ENCRYPTION_KEY"))
+
+	// Setting the login attempts limits
+	LoginFreeAttempts = intFromEnv("LOGIN_FREE_ATTEMPTS", 3)
+	LoginIPFreeAttempts = intFromEnv("LOGIN_IP_FREE_ATTEMPTS", 20)
+	LoginLockoutAttempts = intFromEnv("LOGIN_LOCKOUT_ATTEMPTS", 10)
+	LoginIPLockoutAttempts = intFromEnv("LOGIN_IP_LOCKOUT_ATTEMPTS", 100)
+	LoginLockoutDuration = durationFromEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute)
+	TrustProxyHeaders = os.Getenv("TRUST_PROXY_HEADERS") == "true"
+
+	// Setting the email settings
+	AppURL = strings.TrimSuffix(os.Getenv("APP_URL"), "/")
+	Mailer = os.Getenv("MAILER")
+	if Mailer == "" {
+		// Default mailer
+		Mailer = "log"
+	}
+	MailLogFile = os.Getenv("MAIL_LOG_FILE")
+	MailFrom = os.Getenv("MAIL_FROM")
+	if MailFrom == "" {
+		// Default sender address
+		MailFrom = "DevBook <no-reply@devbook.local>"
+	}
+	SMTPHost = os.Getenv("SMTP_HOST")
+	SMTPPort = intFromEnv("SMTP_PORT", 587)
+	SMTPUser = os.Getenv("SMTP_USER")
+	SMTPPass = os.Getenv("SMTP_PASS")
+	EmailVerificationDuration = durationFromEnv("EMAIL_VERIFICATION_DURATION", 24*time.Hour)
+	PasswordResetDuration = durationFromEnv("PASSWORD_RESET_DURATION", time.Hour)
+
+	// Setting the password policy
+	PasswordMinLength = intFromEnv("PASSWORD_MIN_LENGTH", 8)
+	PasswordRequiredClasses = nil
+	for _, class := range strings.Split(os.Getenv("PASSWORD_REQUIRED_CLASSES"), ",") {
+		if class = strings.TrimSpace(class); class != "" {
+			PasswordRequiredClasses = append(PasswordRequiredClasses, class)
+		}
+	}
+	BreachedPasswordsDir = os.Getenv("BREACHED_PASSWORDS_DIR")
+
+	// Setting the password hashing algorithm and its cost parameters
+	PasswordHasher = os.Getenv("PASSWORD_HASHER")
+	if PasswordHasher == "" {
+		// Default password hashing algorithm
+		PasswordHasher = "bcrypt"
+	}
+	BcryptCost = intFromEnv("BCRYPT_COST", 10)
+	Argon2Time = intFromEnv("ARGON2_TIME", 2)
+	Argon2Memory = intFromEnv("ARGON2_MEMORY", 19*1024)
+	Argon2Threads = intFromEnv("ARGON2_THREADS", 1)
+}
+
+// stringFromEnv reads a string from an environment variable, returning the default value if it's empty
+func stringFromEnv(key string, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+// intFromEnv reads an integer from an environment variable, returning the default value if it's not valid
+func intFromEnv(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// durationFromEnv reads a duration from an environment variable, returning the default value if it's not valid
+func durationFromEnv(key string, defaultValue time.Duration) time.Duration {
+	duration, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || duration <= 0 {
+		return defaultValue
+	}
+	return duration
 }
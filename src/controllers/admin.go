@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ChangeUserRole updates a specific user role on the database
+func (handler *Handler) ChangeUserRole(w http.ResponseWriter, r *http.Request) {
+	// Getting the request parameters
+	params := mux.Vars(r)
+
+	// Getting the user ID
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Getting the user ID provided on the token
+	tokenUserID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// If user is trying to change its own role (which could leave no admins)
+	if userID == tokenUserID {
+		responses.Error(w, http.StatusForbidden, errors.New("You cannot change your own role"))
+		return
+	}
+
+	// Getting request body
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// Initializing the role change, reading data from the request body
+	var role models.Role
+	if err = json.Unmarshal(requestBody, &role); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if !authentication.IsValidRole(role.Role) {
+		responses.Error(w, http.StatusBadRequest, errors.New("Role is not valid"))
+		return
+	}
+
+	// Changing user's role and invalidating the tokens issued with the old one, in a unit of work
+	if !handler.runUnitOfWork(w, r, func(unit repositories.UnitOfWork) error {
+		if err := unit.Users.ChangeRole(r.Context(), userID, role.Role); err != nil {
+			return err
+		}
+		return invalidateUserTokens(r.Context(), unit, userID)
+	}) {
+		return
+	}
+
+	// If everything is ok
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// RevokeUserTokens invalidates all tokens issued to a specific user (e.g. when the account was compromised)
+func (handler *Handler) RevokeUserTokens(w http.ResponseWriter, r *http.Request) {
+	// Getting the request parameters
+	params := mux.Vars(r)
+
+	// Getting the user ID
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Invalidating the user tokens, in a unit of work so they're either all revoked or none is
+	if !handler.runUnitOfWork(w, r, func(unit repositories.UnitOfWork) error {
+		return invalidateUserTokens(r.Context(), unit, userID)
+	}) {
+		return
+	}
+
+	// If everything is ok
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// RestoreUser brings back a deleted user (before it's purged), along with its posts
+func (handler *Handler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	// Getting the request parameters
+	params := mux.Vars(r)
+
+	// Getting the user ID
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Restoring the user
+	restored, err := handler.users.Restore(r.Context(), userID)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !restored {
+		responses.Error(w, http.StatusNotFound, errors.New("Deleted user not found"))
+		return
+	}
+
+	// If everything is ok
+	responses.JSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,82 @@
+package controllers_test
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/controllers"
+	"api/src/database"
+	"api/src/mail"
+	"api/src/middlewares"
+	"api/src/repositories"
+	"api/src/router"
+	"api/src/throttling"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestServer creates the API routes on an empty in-memory SQLite database, along with the services they use
+func newTestServer(t *testing.T) http.Handler {
+	t.Helper()
+
+	// Opening the database (each test has its own one) and applying the migrations
+	config.DbDriver = "sqlite"
+	config.DbConnString = fmt.Sprintf(
+		"file:/%s.db?vfs=memdb&%s", strings.ReplaceAll(t.Name(), "/", "_"), config.SQLiteConnParams,
+	)
+	db, err := database.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err = db.MigrateUp(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// Setting up the tokens keys and revocation store
+	config.SecretKey = []byte("secret")
+	if err = authentication.LoadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	authentication.SetRevocationStore(repositories.NewRevokedTokensRepository(db))
+
+	// Creating the router, with throttles which never delay the attempts
+	store := repositories.NewDatabaseStore(db)
+	throttle := throttling.NewThrottle(throttling.Policy{}, throttling.SystemClock{})
+	handler := controllers.NewHandler(
+		db, store, repositories.NewPostsSearchRepository(db), mail.NewLogMailer(io.Discard, config.MailFrom), throttle, throttle,
+	)
+	return router.Generate(handler, middlewares.New(db, store.Users()))
+}
+
+// request sends a request to the server (authenticated if a token is provided), returning the response
+// The body is encoded as JSON, and the response body is decoded into the result (if provided)
+func request(t *testing.T, server http.Handler, method, uri, token string, body, result interface{}) int {
+	t.Helper()
+
+	var requestBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&requestBody).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := httptest.NewRequest(method, uri, &requestBody)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+
+	if result != nil && w.Code < 300 {
+		if err := json.Unmarshal(w.Body.Bytes(), result); err != nil {
+			t.Fatalf("%s %s: %v", method, uri, err)
+		}
+	}
+	return w.Code
+}
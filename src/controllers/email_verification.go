@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/mail"
+	"api/src/models"
+	"api/src/responses"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// VerifyEmail confirms the user's email address with the token sent by email
+func (handler *Handler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	// Getting request body
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// Reading the verification token from the request body
+	var verification models.EmailVerification
+	if err = json.Unmarshal(requestBody, &verification); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Checking if the verification token is valid
+	claims, err := authentication.ParsePurposeToken(verification.Token, authentication.PurposeEmailVerification)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// Getting the users' repository
+	repository := handler.users
+	// Verifying the email, which must still be the user's email
+	verified, err := repository.VerifyEmail(r.Context(), claims.UserID, claims.Email)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !verified {
+		responses.Error(w, http.StatusConflict, errors.New("Email has changed or was already verified"))
+		return
+	}
+
+	// If everything is ok
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// ResendVerificationEmail sends a new verification link to the user's email address
+func (handler *Handler) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	// Getting the request parameters
+	params := mux.Vars(r)
+
+	// Getting the user ID
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Getting the user ID provided on the token
+	tokenUserID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// If user is trying to verify another user's email
+	if userID != tokenUserID {
+		responses.Error(w, http.StatusForbidden, errors.New("You cannot verify another user's email"))
+		return
+	}
+
+	// Searching user on the repository
+	user, err := handler.users.SearchByID(r.Context(), userID)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user.EmailVerifiedAt != nil {
+		responses.Error(w, http.StatusConflict, errors.New("Email is already verified"))
+		return
+	}
+
+	// Sending the verification link
+	if err = handler.sendVerificationEmail(user); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// If everything is ok
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// sendVerificationEmail sends a link to confirm the user's email address
+// The link token is bound to the email, so it can't verify an email set later
+func (handler *Handler) sendVerificationEmail(user models.User) error {
+	if handler.mailer == nil {
+		return errors.New("No mailer has been set up")
+	}
+
+	// Creating the verification token
+	token, err := authentication.CreatePurposeToken(
+		authentication.Claims{UserID: user.ID, Email: user.Email},
+		authentication.PurposeEmailVerification,
+		config.EmailVerificationDuration,
+	)
+	if err != nil {
+		return err
+	}
+
+	// Sending the message
+	link := fmt.Sprintf("%s/verify-email?token=%s", config.AppURL, url.QueryEscape(token))
+	return handler.mailer.Send(mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your DevBook email",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s.\n",
+			user.Name, link, config.EmailVerificationDuration,
+		),
+	})
+}
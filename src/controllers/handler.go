@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"api/src/database"
+	"api/src/mail"
+	"api/src/repositories"
+	"api/src/responses"
+	"api/src/throttling"
+	"errors"
+	"net/http"
+)
+
+// Handler holds the dependencies shared by the request handlers, created once when the application starts
+type Handler struct {
+	// Database connection pool, shared by all requests
+	db *database.DB
+	// Store which keeps the users and posts data (on the database or on memory) and runs the units of work on it,
+	// along with its repositories
+	store repositories.Store
+	users repositories.UserStore
+	posts repositories.PostStore
+	// Full-text search over the posts
+	search repositories.PostSearcher
+	// Service used to send email messages
+	mailer mail.Mailer
+	// Throttles for failed login attempts, by account (email, username or user ID) and by client IP address
+	accountsThrottle  *throttling.Throttle
+	addressesThrottle *throttling.Throttle
+}
+
+// NewHandler instantiates/initializes the request handlers with their dependencies
+func NewHandler(
+	db *database.DB,
+	store repositories.Store,
+	search repositories.PostSearcher,
+	mailer mail.Mailer,
+	accountsThrottle, addressesThrottle *throttling.Throttle,
+) *Handler {
+	return &Handler{db, store, store.Users(), store.Posts(), search, mailer, accountsThrottle, addressesThrottle}
+}
+
+// statusError is an error which must be responded with a specific status code, returned from a unit of work
+// to roll it back when the request is invalid (e.g. the user isn't allowed to change the data)
+type statusError struct {
+	status int
+	err    error
+}
+
+func (statusError *statusError) Error() string {
+	return statusError.err.Error()
+}
+
+// runUnitOfWork runs a multi-step operation on the store (see repositories.Store)
+// If it fails, the error is responded and false is returned, so the handler must stop
+func (handler *Handler) runUnitOfWork(w http.ResponseWriter, r *http.Request, work func(unit repositories.UnitOfWork) error) bool {
+	err := handler.store.RunUnitOfWork(r.Context(), work)
+	if err == nil {
+		return true
+	}
+
+	// Responding with the status code of the request errors, and as internal errors otherwise
+	var requestErr *statusError
+	if errors.As(err, &requestErr) {
+		responses.Error(w, requestErr.status, requestErr.err)
+	} else {
+		responses.Error(w, http.StatusInternalServerError, err)
+	}
+	return false
+}
@@ -0,0 +1,16 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/responses"
+	"net/http"
+)
+
+// SearchJWKS returns the public keys used to verify the tokens signature, so other services can verify them
+func (handler *Handler) SearchJWKS(w http.ResponseWriter, r *http.Request) {
+	// Allowing clients to cache the keys for a while
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	// Returning the keys response
+	responses.JSON(w, http.StatusOK, authentication.JWKS())
+}
@@ -2,18 +2,27 @@ package controllers
 
 import (
 	"api/src/authentication"
-	"api/src/database"
 	"api/src/models"
 	"api/src/repositories"
 	"api/src/responses"
 	"api/src/security"
+	"context"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
+// twoFactorChallengeDuration defines how long users have to provide the second factor after the password
+const twoFactorChallengeDuration = 5 * time.Minute
+
 // Login is the function which allows user to authenticate and use the API
-func Login(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	// Getting request body
 	requestBody, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -22,47 +31,230 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Initializing the user, reading data from the request body
-	var user models.User
-	if err = json.Unmarshal(requestBody, &user); err != nil {
+	// Initializing the credentials, reading data from the request body
+	var credentials models.Credentials
+	if err = json.Unmarshal(requestBody, &credentials); err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusBadRequest, err)
 		return
 	}
+	if err = credentials.Prepare(); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Checking if login attempts for the account are allowed (failed attempts are throttled)
+	accountKey := "login:" + strings.ToLower(credentials.Login)
+	if !handler.checkLoginThrottle(w, r, accountKey) {
+		return
+	}
 
-	// Connecting to the database
-	db, err := database.Connect()
+	// Getting the users' repository
+	repository := handler.users
+	// Searching the user on the repository, by its email or username
+	var databaseSavedUser models.User
+	if credentials.IsEmail() {
+		databaseSavedUser, err = repository.SearchByEmail(r.Context(), credentials.Login)
+	} else {
+		databaseSavedUser, err = repository.SearchByUsername(r.Context(), credentials.Login)
+	}
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer db.Close()
 
-	// Creating the users' repository
-	repository := repositories.NewUsersRepository(db)
-	// Creating a new user on the repository
-	databaseSavedUser, err := repository.SearchByEmail(user.Email)
+	// Checking if password is correct
+	// Unknown users get the same response, and a password is checked anyway, so the response time is similar
+	passwordHash := databaseSavedUser.Pass
+	if databaseSavedUser.ID == 0 {
+		passwordHash = dummyPasswordHash()
+	}
+	if err = security.CheckPassword(credentials.Password, passwordHash); err != nil || databaseSavedUser.ID == 0 {
+		// Registering the failed attempt
+		handler.registerLoginFailure(r, accountKey)
+		responses.Error(w, http.StatusUnauthorized, errors.New("Invalid login or password"))
+		return
+	}
+
+	// Forgetting previous failed attempts
+	handler.resetLoginThrottle(accountKey)
+
+	// Upgrading the password hash, if its algorithm or parameters are out of date
+	if security.NeedsRehash(databaseSavedUser.Pass) {
+		rehashPassword(r.Context(), repository, databaseSavedUser.ID, databaseSavedUser.Pass, credentials.Password)
+	}
+
+	// If two-factor authentication is enabled, a challenge token is returned instead of the user tokens
+	if databaseSavedUser.TwoFactorEnabled {
+		challengeToken, err := authentication.CreatePurposeToken(
+			authentication.Claims{UserID: databaseSavedUser.ID},
+			authentication.PurposeTwoFactorChallenge,
+			twoFactorChallengeDuration,
+		)
+		if err != nil {
+			// If something goes wrong, we call the error response handling function
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		responses.JSON(w, http.StatusOK, models.TwoFactorChallenge{
+			TwoFactorRequired: true,
+			ChallengeToken:    challengeToken,
+		})
+		return
+	}
+
+	// Completing the login
+	handler.completeLogin(w, r, databaseSavedUser)
+}
+
+// LoginTwoFactor completes a login which requires a second factor, exchanging the challenge token for the user tokens
+func (handler *Handler) LoginTwoFactor(w http.ResponseWriter, r *http.Request) {
+	// Getting request body
+	requestBody, err := ioutil.ReadAll(r.Body)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+		responses.Error(w, http.StatusUnprocessableEntity, err)
 		return
 	}
 
-	// Checking if password is correct
-	if err = security.CheckPassword(user.Pass, databaseSavedUser.Pass); err != nil {
+	// Initializing the two-factor login, reading data from the request body
+	var login models.TwoFactorLogin
+	if err = json.Unmarshal(requestBody, &login); err != nil {
 		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if login.Code == "" && login.RecoveryCode == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("Code or RecoveryCode must be provided"))
+		return
+	}
+
+	// Checking the challenge token (issued after the password was checked)
+	claims, err := authentication.ParsePurposeToken(login.ChallengeToken, authentication.PurposeTwoFactorChallenge)
+	if err != nil {
 		responses.Error(w, http.StatusUnauthorized, err)
 		return
 	}
+	revoked, err := authentication.IsRevoked(r.Context(), claims)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	if revoked {
+		responses.Error(w, http.StatusUnauthorized, errors.New("Challenge token was already used"))
+		return
+	}
 
-	// Generating the user token
-	token, err := authentication.CreateToken(databaseSavedUser.ID)
+	// Checking if two-factor attempts for the account are allowed (failed attempts are throttled)
+	accountKey := "2fa:" + strconv.FormatUint(claims.UserID, 10)
+	if !handler.checkLoginThrottle(w, r, accountKey) {
+		return
+	}
+
+	// Checking the second factor (TOTP or recovery code)
+	verified, err := handler.verifySecondFactor(r.Context(), claims.UserID, login)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
+	if !verified {
+		// Registering the failed attempt
+		handler.registerLoginFailure(r, accountKey)
+		responses.Error(w, http.StatusUnauthorized, errors.New("Invalid two-factor code"))
+		return
+	}
+
+	// Forgetting previous failed attempts
+	handler.resetLoginThrottle(accountKey)
+
+	// Revoking the challenge token, so it can't be used again
+	if err = authentication.RevokeToken(r.Context(), claims); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Getting the user role and token version
+	user, err := handler.users.SearchTokenData(r.Context(), claims.UserID)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user.ID == 0 {
+		responses.Error(w, http.StatusUnauthorized, errors.New("Invalid token"))
+		return
+	}
+
+	// Completing the login
+	handler.completeLogin(w, r, user)
+}
+
+// rehashPassword stores a new hash for the user's password, created with the current algorithm and parameters
+// The login doesn't fail if it goes wrong, since the old hash is still valid
+// The hash is only replaced if it's still the old one, so a password changed meanwhile isn't overwritten
+func rehashPassword(ctx context.Context, repository repositories.UserStore, userID uint64, currentHashPass, password string) {
+	hashPassword, err := security.Hash(password)
+	if err == nil {
+		_, err = repository.ReplacePassword(ctx, userID, currentHashPass, string(hashPassword))
+	}
+	if err != nil {
+		log.Printf("Error rehashing password of user %d: %v", userID, err)
+	}
+}
+
+// completeLogin generates the tokens for an authenticated user, on a new session (refresh tokens family)
+func (handler *Handler) completeLogin(w http.ResponseWriter, r *http.Request, user models.User) {
+	// Creating a new session for this login
+	familyID, err := createSession(handler.db, r, user.ID)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Generating the user tokens (access and refresh tokens)
+	authenticationData, err := createAuthenticationData(r.Context(),
+		repositories.NewRefreshTokensRepository(handler.db), user, familyID,
+	)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Adding the user basic profile
+	profile, err := handler.users.SearchByID(r.Context(), user.ID)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	profile.Role = user.Role
+	authenticationData.User = &profile
+
+	// Returning the user tokens
+	responses.JSON(w, http.StatusOK, authenticationData)
+}
+
+// Defining the hash checked for unknown users
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
 
-	w.Write([]byte(token))
+// dummyPasswordHash returns a hash created with the current algorithm, checked when the user doesn't exist
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		hashPassword, err := security.Hash("dummy-password")
+		if err != nil {
+			log.Printf("Error creating the dummy password hash: %v", err)
+		}
+		dummyHash = string(hashPassword)
+	})
+	return dummyHash
 }
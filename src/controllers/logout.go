@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+)
+
+// Logout revokes the token used on the request, ending its session (and the refresh token session, if provided)
+func (handler *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	// Getting the claims provided on the token
+	claims, err := authentication.ExtractClaims(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// Getting request body
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// Initializing the refresh request, reading data from the request body (which is optional)
+	var refreshRequest models.RefreshRequest
+	if len(requestBody) > 0 {
+		if err = json.Unmarshal(requestBody, &refreshRequest); err != nil {
+			// If something goes wrong, we call the error response handling function
+			responses.Error(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	// Revoking the access token
+	if err = authentication.RevokeToken(r.Context(), claims); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Ending the session of the access token (personal access tokens have no session)
+	if claims.SessionID != "" {
+		if _, err = revokeSession(r.Context(), handler.db, claims.UserID, claims.SessionID); err != nil {
+			// If something goes wrong, we call the error response handling function
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	// If a refresh token was provided, its session is ended as well
+	if refreshRequest.RefreshToken != "" {
+		// Searching the refresh token on the repository
+		savedToken, err := repositories.NewRefreshTokensRepository(handler.db).SearchByHash(r.Context(), authentication.HashToken(refreshRequest.RefreshToken))
+		if err != nil {
+			// If something goes wrong, we call the error response handling function
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// Users can only revoke their own refresh tokens
+		if savedToken.ID != 0 && savedToken.UserID == claims.UserID && savedToken.FamilyID != claims.SessionID {
+			if _, err = revokeSession(r.Context(), handler.db, claims.UserID, savedToken.FamilyID); err != nil {
+				// If something goes wrong, we call the error response handling function
+				responses.Error(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+	}
+
+	// If everything is ok
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// LogoutAll revokes all tokens (access and refresh tokens) issued to the user
+func (handler *Handler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	// Getting the user ID provided on the token
+	tokenUserID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// Revoking all user refresh tokens, so no new access tokens can be issued
+	if err = repositories.NewRefreshTokensRepository(handler.db).RevokeByUser(r.Context(), tokenUserID); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Ending all user sessions
+	if err = repositories.NewSessionsRepository(handler.db).RevokeByUser(r.Context(), tokenUserID); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Revoking all user access tokens
+	if err = authentication.RevokeUserTokens(r.Context(), tokenUserID); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// If everything is ok
+	responses.JSON(w, http.StatusNoContent, nil)
+}
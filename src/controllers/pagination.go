@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"api/src/models"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// defaultPageLimit defines how many items are returned on each page, unless the request sets the limit
+	defaultPageLimit = 20
+	// maxPageLimit defines the largest limit accepted on requests
+	maxPageLimit = 100
+)
+
+// readPageRequest reads the pagination parameters from the query string: "limit", and either the "after" cursor
+// (which selects the next page) or the "before" cursor (which selects the previous page)
+func readPageRequest(r *http.Request) (models.PageRequest, error) {
+	query := r.URL.Query()
+
+	// Reading the page size
+	limit, err := readLimit(r)
+	if err != nil {
+		return models.PageRequest{}, err
+	}
+	request := models.PageRequest{Limit: limit}
+
+	// Reading the cursor (only one direction may be requested)
+	after, before := query.Get("after"), query.Get("before")
+	if after != "" && before != "" {
+		return models.PageRequest{}, errors.New("Only one of the after and before cursors may be provided")
+	}
+	if after != "" {
+		request.After, err = decodeCursor(after)
+	} else if before != "" {
+		request.Before, err = decodeCursor(before)
+	}
+	if err != nil {
+		return models.PageRequest{}, err
+	}
+
+	return request, nil
+}
+
+// readLimit reads how many items are requested from the "limit" query parameter (or the default page limit)
+func readLimit(r *http.Request) (int, error) {
+	limit := r.URL.Query().Get("limit")
+	if limit == "" {
+		return defaultPageLimit, nil
+	}
+	value, err := strconv.Atoi(limit)
+	if err != nil || value < 1 || value > maxPageLimit {
+		return 0, errors.New("The limit must be a number from 1 to " + strconv.Itoa(maxPageLimit))
+	}
+	return value, nil
+}
+
+// newPage creates the response for a page of a list, with the cursors which select the pages around it
+// The items must be in the list order, and more tells whether there are more items beyond the page
+// (in the direction it was requested)
+func newPage[T any](items []T, more bool, request models.PageRequest, ID func(item T) uint64) models.Page[T] {
+	page := models.Page[T]{Items: items}
+	if len(items) == 0 {
+		// Empty lists are returned as an empty array
+		page.Items = []T{}
+		return page
+	}
+
+	// Previous pages are followed by the item of the cursor, and next pages are preceded by it
+	hasNext, hasPrevious := more, request.After != 0
+	if request.Backward() {
+		hasNext, hasPrevious = true, more
+	}
+	if hasNext {
+		page.NextCursor = encodeCursor(ID(items[len(items)-1]))
+	}
+	if hasPrevious {
+		page.PreviousCursor = encodeCursor(ID(items[0]))
+	}
+	return page
+}
+
+// encodeCursor creates the opaque cursor which points to the item with the ID
+func encodeCursor(ID uint64) string {
+	bytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(bytes, ID)
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
+
+// decodeCursor reads the ID of the item pointed by a cursor created by encodeCursor
+func decodeCursor(cursor string) (uint64, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(bytes) != 8 || binary.BigEndian.Uint64(bytes) == 0 {
+		return 0, errors.New("Invalid page cursor")
+	}
+	return binary.BigEndian.Uint64(bytes), nil
+}
+
+// postKey returns the ID of a post, which orders the posts lists
+func postKey(post models.Post) uint64 {
+	return post.ID
+}
+
+// userKey returns the ID of an user, which orders the users lists
+func userKey(user models.User) uint64 {
+	return user.ID
+}
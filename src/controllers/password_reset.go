@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/mail"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"api/src/security"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ForgotPassword sends a password reset link to the provided email, if it belongs to an user
+// The response is the same whether the email is registered or not, so it can't be used to find accounts
+func (handler *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	// Getting request body
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// Reading the email from the request body
+	var forgot models.PasswordForgot
+	if err = json.Unmarshal(requestBody, &forgot); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	forgot.Email = strings.TrimSpace(forgot.Email)
+	if forgot.Email == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("Email is a required field, cannot be left blank"))
+		return
+	}
+
+	// Searching user on the repository
+	user, err := handler.users.SearchByEmail(r.Context(), forgot.Email)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// A reset link is only created for registered emails
+	if user.ID != 0 {
+		repository := repositories.NewPasswordResetTokensRepository(handler.db)
+		// Only the latest link can be used
+		if err = repository.RevokeByUser(r.Context(), user.ID); err != nil {
+			// If something goes wrong, we call the error response handling function
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// Creating the reset token (only its hash is stored)
+		token, tokenHash, err := authentication.CreatePasswordResetToken()
+		if err != nil {
+			// If something goes wrong, we call the error response handling function
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+		if _, err = repository.Create(r.Context(), models.PasswordResetToken{
+			UserID:    user.ID,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(config.PasswordResetDuration),
+		}); err != nil {
+			// If something goes wrong, we call the error response handling function
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		// The message is sent in the background, so the response time doesn't reveal the email is registered
+		go func(userID uint64) {
+			if err := handler.sendPasswordResetEmail(forgot.Email, token); err != nil {
+				log.Printf("Error sending password reset email to user %d: %v", userID, err)
+			}
+		}(user.ID)
+	}
+
+	// If everything is ok
+	responses.JSON(w, http.StatusAccepted, nil)
+}
+
+// ResetPassword sets a new password with the token sent by email, invalidating the user's sessions
+func (handler *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	// Getting request body
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// Reading the reset token and the new password from the request body
+	var reset models.PasswordReset
+	if err = json.Unmarshal(requestBody, &reset); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Searching the reset token on the repository (only its hash is stored)
+	repository := repositories.NewPasswordResetTokensRepository(handler.db)
+	savedToken, err := repository.SearchByHash(r.Context(), authentication.HashToken(reset.Token))
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Checking if the token exists and is still valid
+	if savedToken.ID == 0 || savedToken.UsedAt != nil || time.Now().After(savedToken.ExpiresAt) {
+		responses.Error(w, http.StatusBadRequest, errors.New("Invalid or expired password reset token"))
+		return
+	}
+
+	// Checking if the new password follows the password policy (before the token is used, so it can be tried again)
+	if !validateNewPassword(r.Context(), w, handler.users, savedToken.UserID, reset.New) {
+		return
+	}
+
+	// Creating the new password hash
+	hashPassword, err := security.Hash(reset.New)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Using the token and changing the password in a unit of work, so the token isn't spent if the change fails
+	if !handler.runUnitOfWork(w, r, func(unit repositories.UnitOfWork) error {
+		// Marking the token as used, so it can't be used again (e.g. by a concurrent request)
+		used, err := unit.PasswordResetTokens.Use(r.Context(), savedToken.ID)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return &statusError{http.StatusBadRequest, errors.New("Invalid or expired password reset token")}
+		}
+
+		// Changing user's password
+		if err = unit.Users.ChangePassword(r.Context(), savedToken.UserID, string(hashPassword)); err != nil {
+			return err
+		}
+
+		// Other reset links sent to the user can't be used anymore
+		if err = unit.PasswordResetTokens.RevokeByUser(r.Context(), savedToken.UserID); err != nil {
+			return err
+		}
+
+		// Invalidating the tokens issued with the old password
+		return invalidateUserTokens(r.Context(), unit, savedToken.UserID)
+	}) {
+		return
+	}
+
+	// If everything is ok
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// sendPasswordResetEmail sends the link to reset the password of the account with the email
+func (handler *Handler) sendPasswordResetEmail(email, token string) error {
+	if handler.mailer == nil {
+		return errors.New("No mailer has been set up")
+	}
+
+	// Sending the message
+	link := fmt.Sprintf("%s/reset-password?token=%s", config.AppURL, url.QueryEscape(token))
+	return handler.mailer.Send(mail.Message{
+		To:      email,
+		Subject: "Reset your DevBook password",
+		Body: fmt.Sprintf(
+			"Hi,\n\nA password reset was requested for your account. To choose a new password, open the link below:\n\n%s\n\n"+
+				"The link expires in %s. If you didn't request it, you can ignore this message.\n",
+			link, config.PasswordResetDuration,
+		),
+	})
+}
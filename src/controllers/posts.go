@@ -2,7 +2,6 @@ package controllers
 
 import (
 	"api/src/authentication"
-	"api/src/database"
 	"api/src/models"
 	"api/src/repositories"
 	"api/src/responses"
@@ -11,12 +10,13 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 // CreatePost inserts a new post on the database
-func CreatePost(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	// Getting the user ID provided on the token
 	tokenUserID, err := authentication.ExtractUserID(r)
 	if err != nil {
@@ -50,19 +50,10 @@ func CreatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
-	if err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
-		return
-	}
-	defer db.Close()
-
-	// Creating the posts' repository
-	repository := repositories.NewPostsRepository(db)
+	// Getting the posts' repository
+	repository := handler.posts
 	// Creating a new post on the repository
-	post.ID, err = repository.Create(post)
+	post.ID, err = repository.Create(r.Context(), post)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
@@ -74,7 +65,7 @@ func CreatePost(w http.ResponseWriter, r *http.Request) {
 }
 
 // SearchPosts searchs users and following users posts (user's feed)
-func SearchPosts(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) SearchPosts(w http.ResponseWriter, r *http.Request) {
 	// Getting the user ID provided on the token
 	tokenUserID, err := authentication.ExtractUserID(r)
 	if err != nil {
@@ -82,19 +73,17 @@ func SearchPosts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
+	// Getting the requested page
+	page, err := readPageRequest(r)
 	if err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+		responses.Error(w, http.StatusBadRequest, err)
 		return
 	}
-	defer db.Close()
 
-	// Creating the posts' repository
-	repository := repositories.NewPostsRepository(db)
+	// Getting the posts' repository
+	repository := handler.posts
 	// Searching posts on the repository
-	posts, err := repository.Search(tokenUserID)
+	posts, more, err := repository.Search(r.Context(), tokenUserID, page)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
@@ -102,46 +91,68 @@ func SearchPosts(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Returning posts response
-	responses.JSON(w, http.StatusOK, posts)
+	responses.JSON(w, http.StatusOK, newPage(posts, more, page, postKey))
 }
 
-// SearchPost search a specific post from the database
-func SearchPost(w http.ResponseWriter, r *http.Request) {
-	// Getting the request parameters
-	params := mux.Vars(r)
-
-	// Getting the post ID
-	postID, err := strconv.ParseUint(params["postId"], 10, 64)
+// SearchPostsByKeywords searchs the posts matching a full-text query (most relevant first)
+// The query ("q") may have words, "quoted phrases" and prefixes (words ending with *), and the posts may be
+// filtered by author ("authorId") and creation date ("from" and "to", as dates or RFC 3339 times)
+func (handler *Handler) SearchPostsByKeywords(w http.ResponseWriter, r *http.Request) {
+	// Getting the user ID provided on the token
+	tokenUserID, err := authentication.ExtractUserID(r)
 	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// Initializing the search, reading data from the query string
+	query := r.URL.Query()
+	search := models.PostSearch{Query: query.Get("q")}
+	if search.Limit, err = readLimit(r); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if authorID := query.Get("authorId"); authorID != "" {
+		if search.AuthorID, err = strconv.ParseUint(authorID, 10, 64); err != nil {
+			responses.Error(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if search.From, err = readSearchTime(query.Get("from"), false); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if search.To, err = readSearchTime(query.Get("to"), true); err != nil {
 		responses.Error(w, http.StatusBadRequest, err)
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
-	if err != nil {
+	// Preparing the search terms
+	if err = search.Prepare(); err != nil {
 		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+		responses.Error(w, http.StatusBadRequest, err)
 		return
 	}
-	defer db.Close()
 
-	// Creating the posts' repository
-	repository := repositories.NewPostsRepository(db)
-	// Searching post on the repository
-	post, err := repository.SearchByID(postID)
+	// Getting the posts search repository
+	repository := handler.search
+	// Searching posts on the repository
+	results, err := repository.Search(r.Context(), search, tokenUserID)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
+	if results == nil {
+		results = []models.PostSearchResult{}
+	}
 
-	// Returning post response
-	responses.JSON(w, http.StatusOK, post)
+	// Returning search results response (only the most relevant ones, so there are no other pages)
+	responses.JSON(w, http.StatusOK, models.Page[models.PostSearchResult]{Items: results})
 }
 
-// UpdatePost updates a specific post on the database
-func UpdatePost(w http.ResponseWriter, r *http.Request) {
+// SearchPost search a specific post from the database
+func (handler *Handler) SearchPost(w http.ResponseWriter, r *http.Request) {
 	// Getting the request parameters
 	params := mux.Vars(r)
 
@@ -153,35 +164,42 @@ func UpdatePost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Getting the user ID provided on the token
-	userID, err := authentication.ExtractUserID(r)
+	tokenUserID, err := authentication.ExtractUserID(r)
 	if err != nil {
 		responses.Error(w, http.StatusUnauthorized, err)
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
+	// Getting the posts' repository
+	repository := handler.posts
+	// Searching post on the repository
+	post, err := repository.SearchByID(r.Context(), postID, tokenUserID)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer db.Close()
 
-	// Creating the posts' repository
-	repository := repositories.NewPostsRepository(db)
+	// Returning post response
+	responses.JSON(w, http.StatusOK, post)
+}
+
+// UpdatePost updates a specific post on the database
+func (handler *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
+	// Getting the request parameters
+	params := mux.Vars(r)
 
-	// Getting the post saved on the databse by the ID provided
-	savedPost, err := repository.SearchByID(postID)
+	// Getting the post ID
+	postID, err := strconv.ParseUint(params["postId"], 10, 64)
 	if err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+		responses.Error(w, http.StatusBadRequest, err)
 		return
 	}
 
-	// If user is trying to update another user's post
-	if savedPost.AuthorID != userID {
-		responses.Error(w, http.StatusForbidden, errors.New("You cannot update another user's post"))
+	// Getting the claims provided on the token
+	claims, err := authentication.ExtractClaims(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
 		return
 	}
 
@@ -208,10 +226,25 @@ func UpdatePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Updating the existing post on the repository
-	if err = repository.Update(postID, post); err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+	// Checking the post author and updating it in a unit of work, so the post is locked between both steps
+	if !handler.runUnitOfWork(w, r, func(unit repositories.UnitOfWork) error {
+		// Getting the post saved on the databse by the ID provided
+		savedPost, err := unit.Posts.SearchByIDForUpdate(r.Context(), postID)
+		if err != nil {
+			return err
+		}
+		if savedPost.ID == 0 {
+			return &statusError{http.StatusNotFound, errors.New("Post not found")}
+		}
+
+		// If user is trying to update another user's post (only allowed for moderators)
+		if savedPost.AuthorID != claims.UserID && !claims.HasPermission(authentication.PermissionModeratePosts) {
+			return &statusError{http.StatusForbidden, errors.New("You cannot update another user's post")}
+		}
+
+		// Updating the existing post on the repository
+		return unit.Posts.Update(r.Context(), postID, post)
+	}) {
 		return
 	}
 
@@ -220,7 +253,7 @@ func UpdatePost(w http.ResponseWriter, r *http.Request) {
 }
 
 // DeletePost removes a specific post from the database
-func DeletePost(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
 	// Getting the request parameters
 	params := mux.Vars(r)
 
@@ -231,43 +264,78 @@ func DeletePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Getting the user ID provided on the token
-	userID, err := authentication.ExtractUserID(r)
+	// Getting the claims provided on the token
+	claims, err := authentication.ExtractClaims(r)
 	if err != nil {
 		responses.Error(w, http.StatusUnauthorized, err)
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
-	if err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+	// Checking the post author and deleting it in a unit of work, so the post is locked between both steps
+	if !handler.runUnitOfWork(w, r, func(unit repositories.UnitOfWork) error {
+		// Getting the post saved on the databse by the ID provided
+		savedPost, err := unit.Posts.SearchByIDForUpdate(r.Context(), postID)
+		if err != nil {
+			return err
+		}
+		if savedPost.ID == 0 {
+			return &statusError{http.StatusNotFound, errors.New("Post not found")}
+		}
+
+		// If user is trying to delete another user's post (only allowed for moderators)
+		if savedPost.AuthorID != claims.UserID && !claims.HasPermission(authentication.PermissionModeratePosts) {
+			return &statusError{http.StatusForbidden, errors.New("You cannot delete another user's post")}
+		}
+
+		// Deleting the existing post on the repository
+		return unit.Posts.Delete(r.Context(), postID)
+	}) {
 		return
 	}
-	defer db.Close()
 
-	// Creating the posts' repository
-	repository := repositories.NewPostsRepository(db)
+	// If everything is ok
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// RestorePost brings back a deleted post (before it's purged)
+func (handler *Handler) RestorePost(w http.ResponseWriter, r *http.Request) {
+	// Getting the request parameters
+	params := mux.Vars(r)
 
-	// Getting the post saved on the databse by the ID provided
-	savedPost, err := repository.SearchByID(postID)
+	// Getting the post ID
+	postID, err := strconv.ParseUint(params["postId"], 10, 64)
 	if err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+		responses.Error(w, http.StatusBadRequest, err)
 		return
 	}
 
-	// If user is trying to delete another user's post
-	if savedPost.AuthorID != userID {
-		responses.Error(w, http.StatusForbidden, errors.New("You cannot delete another user's post"))
+	// Getting the claims provided on the token
+	claims, err := authentication.ExtractClaims(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
 		return
 	}
 
-	// Deleting the existing post on the repository
-	if err = repository.Delete(postID); err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+	// Checking the post author and restoring it in a unit of work, so the post is locked between both steps
+	if !handler.runUnitOfWork(w, r, func(unit repositories.UnitOfWork) error {
+		// Getting the deleted post saved on the databse by the ID provided
+		deletedPost, err := unit.Posts.SearchDeletedByIDForUpdate(r.Context(), postID)
+		if err != nil {
+			return err
+		}
+		if deletedPost.ID == 0 {
+			return &statusError{http.StatusNotFound, errors.New("Deleted post not found")}
+		}
+
+		// If user is trying to restore another user's post (only allowed for moderators)
+		if deletedPost.AuthorID != claims.UserID && !claims.HasPermission(authentication.PermissionModeratePosts) {
+			return &statusError{http.StatusForbidden, errors.New("You cannot restore another user's post")}
+		}
+
+		// Restoring the post
+		_, err = unit.Posts.Restore(r.Context(), postID)
+		return err
+	}) {
 		return
 	}
 
@@ -276,7 +344,7 @@ func DeletePost(w http.ResponseWriter, r *http.Request) {
 }
 
 // SearchPostsByUser searchs a specific user posts
-func SearchPostsByUser(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) SearchPostsByUser(w http.ResponseWriter, r *http.Request) {
 	// Getting the request parameters
 	params := mux.Vars(r)
 
@@ -287,19 +355,24 @@ func SearchPostsByUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
+	// Getting the user ID provided on the token
+	tokenUserID, err := authentication.ExtractUserID(r)
 	if err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+		responses.Error(w, http.StatusUnauthorized, err)
 		return
 	}
-	defer db.Close()
 
-	// Creating the posts' repository
-	repository := repositories.NewPostsRepository(db)
+	// Getting the requested page
+	page, err := readPageRequest(r)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Getting the posts' repository
+	repository := handler.posts
 	// Searching posts on the repository
-	posts, err := repository.SearchByUser(userID)
+	posts, more, err := repository.SearchByUser(r.Context(), userID, tokenUserID, page)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
@@ -307,11 +380,11 @@ func SearchPostsByUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Returning posts response
-	responses.JSON(w, http.StatusOK, posts)
+	responses.JSON(w, http.StatusOK, newPage(posts, more, page, postKey))
 }
 
-// LikePost adds 1 to the number of likes in a post
-func LikePost(w http.ResponseWriter, r *http.Request) {
+// LikePost records the user like on a post (liking it again has no effect)
+func (handler *Handler) LikePost(w http.ResponseWriter, r *http.Request) {
 	// Getting the request parameters
 	params := mux.Vars(r)
 
@@ -322,22 +395,28 @@ func LikePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
+	// Getting the user ID provided on the token
+	tokenUserID, err := authentication.ExtractUserID(r)
 	if err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+		responses.Error(w, http.StatusUnauthorized, err)
 		return
 	}
-	defer db.Close()
 
-	// Creating the posts' repository
-	repository := repositories.NewPostsRepository(db)
+	// Liking the post in a unit of work, so the post is locked while its likes counter is updated
+	if !handler.runUnitOfWork(w, r, func(unit repositories.UnitOfWork) error {
+		// Getting the post saved on the databse by the ID provided
+		savedPost, err := unit.Posts.SearchByIDForUpdate(r.Context(), postID)
+		if err != nil {
+			return err
+		}
+		if savedPost.ID == 0 {
+			return &statusError{http.StatusNotFound, errors.New("Post not found")}
+		}
 
-	// Liking the existing post on the repository
-	if err = repository.Like(postID); err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+		// Liking the existing post on the repository
+		_, err = unit.Posts.Like(r.Context(), postID, tokenUserID)
+		return err
+	}) {
 		return
 	}
 
@@ -345,8 +424,8 @@ func LikePost(w http.ResponseWriter, r *http.Request) {
 	responses.JSON(w, http.StatusNoContent, nil)
 }
 
-// DislikePost subtracts 1 from the number of likes in a post
-func DislikePost(w http.ResponseWriter, r *http.Request) {
+// DislikePost removes the user like from a post (disliking a post which isn't liked has no effect)
+func (handler *Handler) DislikePost(w http.ResponseWriter, r *http.Request) {
 	// Getting the request parameters
 	params := mux.Vars(r)
 
@@ -357,25 +436,102 @@ func DislikePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
+	// Getting the user ID provided on the token
+	tokenUserID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// Disliking the post in a unit of work, so the post is locked while its likes counter is updated
+	if !handler.runUnitOfWork(w, r, func(unit repositories.UnitOfWork) error {
+		// Getting the post saved on the databse by the ID provided
+		savedPost, err := unit.Posts.SearchByIDForUpdate(r.Context(), postID)
+		if err != nil {
+			return err
+		}
+		if savedPost.ID == 0 {
+			return &statusError{http.StatusNotFound, errors.New("Post not found")}
+		}
+
+		// Removing the user like from the existing post on the repository
+		_, err = unit.Posts.Dislike(r.Context(), postID, tokenUserID)
+		return err
+	}) {
+		return
+	}
+
+	// If everything is ok
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// SearchPostLikes searchs the users who like a post
+func (handler *Handler) SearchPostLikes(w http.ResponseWriter, r *http.Request) {
+	// Getting the request parameters
+	params := mux.Vars(r)
+
+	// Getting the post ID
+	postID, err := strconv.ParseUint(params["postId"], 10, 64)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Getting the user ID provided on the token
+	tokenUserID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// Getting the requested page
+	page, err := readPageRequest(r)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Checking if the post exists
+	post, err := handler.posts.SearchByID(r.Context(), postID, tokenUserID)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer db.Close()
-
-	// Creating the posts' repository
-	repository := repositories.NewPostsRepository(db)
+	if post.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errors.New("Post not found"))
+		return
+	}
 
-	// Disliking the existing post on the repository
-	if err = repository.Dislike(postID); err != nil {
+	// Getting the users' repository
+	repository := handler.users
+	// Searching the users who like the post on the repository
+	users, more, err := repository.SearchLikers(r.Context(), postID, page)
+	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	// If everything is ok
-	responses.JSON(w, http.StatusNoContent, nil)
+	// Returning users response
+	responses.JSON(w, http.StatusOK, newPage(users, more, page, userKey))
+}
+
+// readSearchTime reads a time from a date ("2006-01-02", in UTC) or RFC 3339 time, returning the zero time if empty
+// Dates ending the search range (end) include the whole day
+func readSearchTime(value string, end bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		if end {
+			date = date.AddDate(0, 0, 1)
+		}
+		return date, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("Invalid date, it must be formatted as 2006-01-02 or as an RFC 3339 time")
+	}
+	return parsed, nil
 }
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/database"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// RefreshToken exchanges a valid refresh token for a new access token and a new (rotated) refresh token
+func (handler *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	// Getting request body
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// Initializing the refresh request, reading data from the request body
+	var refreshRequest models.RefreshRequest
+	if err = json.Unmarshal(requestBody, &refreshRequest); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if refreshRequest.RefreshToken == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("RefreshToken is a required field, cannot be left blank"))
+		return
+	}
+
+	// Creating the refresh tokens' repository
+	repository := repositories.NewRefreshTokensRepository(handler.db)
+	// Searching the refresh token on the repository (only its hash is stored)
+	savedToken, err := repository.SearchByHash(r.Context(), authentication.HashToken(refreshRequest.RefreshToken))
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// If the token doesn't exist
+	if savedToken.ID == 0 {
+		responses.Error(w, http.StatusUnauthorized, errors.New("Invalid refresh token"))
+		return
+	}
+
+	// If the token was already used, it might have been stolen, so the whole family is revoked
+	if savedToken.RevokedAt != nil {
+		revokeTokenFamily(r.Context(), w, handler.db, savedToken.UserID, savedToken.FamilyID)
+		return
+	}
+
+	// If the token is expired
+	if time.Now().After(savedToken.ExpiresAt) {
+		responses.Error(w, http.StatusUnauthorized, errors.New("Refresh token has expired"))
+		return
+	}
+
+	// Revoking the current token, so it can't be used again
+	revoked, err := repository.Revoke(r.Context(), savedToken.ID)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	// If another request used the same token in the meantime, it's also a reuse
+	if !revoked {
+		revokeTokenFamily(r.Context(), w, handler.db, savedToken.UserID, savedToken.FamilyID)
+		return
+	}
+
+	// Getting the user current role and token version, so the new access token is up to date
+	user, err := handler.users.SearchTokenData(r.Context(), savedToken.UserID)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	if user.ID == 0 {
+		responses.Error(w, http.StatusUnauthorized, errors.New("Invalid refresh token"))
+		return
+	}
+
+	// Generating the new tokens, on the same family
+	authenticationData, err := createAuthenticationData(r.Context(), repository, user, savedToken.FamilyID)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Returning the new tokens
+	responses.JSON(w, http.StatusOK, authenticationData)
+}
+
+// createAuthenticationData generates an access token and a refresh token (on the provided family) for the user
+// The user must have its ID, role and current token version set
+func createAuthenticationData(ctx context.Context, repository *repositories.RefreshTokens, user models.User, familyID string) (models.AuthenticationData, error) {
+	// Generating the user access token
+	// The refresh tokens family identifies the user session
+	expiresAt := time.Now().Add(config.AccessTokenDuration)
+	token, err := authentication.CreateToken(authentication.Claims{
+		UserID:       user.ID,
+		Roles:        []string{user.Role},
+		TokenVersion: user.TokenVersion,
+		SessionID:    familyID,
+	})
+	if err != nil {
+		return models.AuthenticationData{}, err
+	}
+
+	// Generating the user refresh token
+	refreshToken, refreshTokenHash, err := authentication.CreateRefreshToken()
+	if err != nil {
+		return models.AuthenticationData{}, err
+	}
+
+	// Storing the refresh token hash on the repository
+	if _, err = repository.Create(ctx, models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: refreshTokenHash,
+		ExpiresAt: time.Now().Add(config.RefreshTokenDuration),
+	}); err != nil {
+		return models.AuthenticationData{}, err
+	}
+
+	// Returning the tokens
+	return models.AuthenticationData{
+		ID:           user.ID,
+		Token:        token,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(config.AccessTokenDuration.Seconds()),
+		ExpiresAt:    expiresAt,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// revokeTokenFamily revokes all tokens from a family after a refresh token reuse was detected
+// The family ID is the session ID, so the session is ended too, and its access tokens are rejected
+func revokeTokenFamily(ctx context.Context, w http.ResponseWriter, db *database.DB, userID uint64, familyID string) {
+	// Revoking the session along with its tokens family
+	revoked, err := revokeSession(ctx, db, userID, familyID)
+	// If the session had already ended, its tokens family is still revoked
+	if err == nil && !revoked {
+		err = repositories.NewRefreshTokensRepository(db).RevokeFamily(ctx, familyID)
+	}
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	responses.Error(w, http.StatusUnauthorized, errors.New("Refresh token was already used, the session has been revoked"))
+}
@@ -0,0 +1,47 @@
+package controllers_test
+
+import (
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestRefreshTokenReuseRevokesTheSession(t *testing.T) {
+	server := newTestServer(t)
+
+	// Registering and logging in
+	user := map[string]string{"name": "User", "username": "user", "email": "user@devbook.local", "pass": "Passw0rd!23"}
+	if status := request(t, server, http.MethodPost, "/users", "", user, nil); status != http.StatusCreated {
+		t.Fatalf("registering: got status %d, want %d", status, http.StatusCreated)
+	}
+	var login models.AuthenticationData
+	credentials := map[string]string{"login": "user", "pass": "Passw0rd!23"}
+	if status := request(t, server, http.MethodPost, "/login", "", credentials, &login); status != http.StatusOK {
+		t.Fatalf("logging in: got status %d, want %d", status, http.StatusOK)
+	}
+
+	// Rotating the refresh token
+	var refreshed models.AuthenticationData
+	refresh := models.RefreshRequest{RefreshToken: login.RefreshToken}
+	if status := request(t, server, http.MethodPost, "/refresh", "", refresh, &refreshed); status != http.StatusOK {
+		t.Fatalf("refreshing: got status %d, want %d", status, http.StatusOK)
+	}
+
+	// Reusing the old refresh token (e.g. after it was stolen) revokes the session
+	if status := request(t, server, http.MethodPost, "/refresh", "", refresh, nil); status != http.StatusUnauthorized {
+		t.Fatalf("reusing the refresh token: got status %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	// Neither the access tokens nor the refresh tokens from the session are accepted anymore
+	profile := fmt.Sprintf("/users/%d", login.ID)
+	for _, token := range []string{login.Token, refreshed.Token} {
+		if status := request(t, server, http.MethodGet, profile, token, nil, nil); status != http.StatusUnauthorized {
+			t.Errorf("using an access token from the session: got status %d, want %d", status, http.StatusUnauthorized)
+		}
+	}
+	refresh = models.RefreshRequest{RefreshToken: refreshed.RefreshToken}
+	if status := request(t, server, http.MethodPost, "/refresh", "", refresh, nil); status != http.StatusUnauthorized {
+		t.Errorf("using the rotated refresh token: got status %d, want %d", status, http.StatusUnauthorized)
+	}
+}
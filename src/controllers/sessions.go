@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/database"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// maxUserAgentLength is the maximum size of the user agent stored with a session
+const maxUserAgentLength = 255
+
+// SearchSessions searchs all active sessions (logged in devices) from the user
+func (handler *Handler) SearchSessions(w http.ResponseWriter, r *http.Request) {
+	// Getting the user ID from the request, checking if it's the authenticated user
+	userID, ok := sessionsUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Getting the claims provided on the token, to identify the current session
+	claims, err := authentication.ExtractClaims(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// Searching sessions on the repository (sessions not used for longer than a refresh token lasts have expired)
+	sessions, err := repositories.NewSessionsRepository(handler.db).SearchByUser(r.Context(),
+		userID, time.Now().Add(-config.RefreshTokenDuration),
+	)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == claims.SessionID
+	}
+
+	// Returning sessions response
+	responses.JSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession ends a specific session from the user, so its tokens stop being accepted
+func (handler *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	// Getting the user ID from the request, checking if it's the authenticated user
+	userID, ok := sessionsUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Getting the session ID
+	sessionID := mux.Vars(r)["sessionId"]
+
+	// Revoking the session on the repository
+	revoked, err := revokeSession(r.Context(), handler.db, userID, sessionID)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !revoked {
+		responses.Error(w, http.StatusNotFound, errors.New("Session not found"))
+		return
+	}
+
+	// If everything is ok
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// createSession stores a new session for the user, with the device which made the request
+// The session ID is used as the refresh tokens family ID
+func createSession(db *database.DB, r *http.Request, userID uint64) (string, error) {
+	// Creating the session ID
+	sessionID, err := authentication.CreateFamilyID()
+	if err != nil {
+		return "", err
+	}
+
+	// Storing the session on the repository
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	if err = repositories.NewSessionsRepository(db).Create(r.Context(), models.Session{
+		ID:        sessionID,
+		UserID:    userID,
+		UserAgent: userAgent,
+		IPAddress: clientIP(r),
+	}); err != nil {
+		return "", err
+	}
+
+	return sessionID, nil
+}
+
+// revokeSession ends a session from the user, revoking its refresh tokens
+// Access tokens from the session are rejected by the authentication middleware
+func revokeSession(ctx context.Context, db *database.DB, userID uint64, sessionID string) (bool, error) {
+	revoked, err := repositories.NewSessionsRepository(db).Revoke(ctx, userID, sessionID)
+	if err != nil || !revoked {
+		return false, err
+	}
+	return true, repositories.NewRefreshTokensRepository(db).RevokeFamily(ctx, sessionID)
+}
+
+// sessionsUserID gets the user ID from the request parameters, checking if it's the authenticated user
+// If it's not, the error response is written and false is returned
+func sessionsUserID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	// Getting the request parameters
+	params := mux.Vars(r)
+
+	// Getting the user ID
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return 0, false
+	}
+
+	// Getting the user ID provided on the token
+	tokenUserID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return 0, false
+	}
+
+	// If user is trying to manage another user's sessions
+	if userID != tokenUserID {
+		responses.Error(w, http.StatusForbidden, errors.New("You cannot manage another user's sessions"))
+		return 0, false
+	}
+
+	return userID, true
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"api/src/config"
+	"api/src/responses"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// checkLoginThrottle checks if login attempts for the account (and from the client IP address) are allowed
+// If they're not, the error response (with the Retry-After header) is written and false is returned
+func (handler *Handler) checkLoginThrottle(w http.ResponseWriter, r *http.Request, accountKey string) bool {
+	if handler.accountsThrottle == nil || handler.addressesThrottle == nil {
+		return true
+	}
+
+	// Checking the account and the client IP address
+	if wait, locked := handler.accountsThrottle.Check(accountKey); wait > 0 {
+		writeThrottled(w, wait, locked, "account")
+		return false
+	}
+	if wait, locked := handler.addressesThrottle.Check(clientIP(r)); wait > 0 {
+		writeThrottled(w, wait, locked, "IP address")
+		return false
+	}
+
+	return true
+}
+
+// registerLoginFailure registers a failed login attempt for the account and from the client IP address
+func (handler *Handler) registerLoginFailure(r *http.Request, accountKey string) {
+	if handler.accountsThrottle == nil || handler.addressesThrottle == nil {
+		return
+	}
+
+	handler.accountsThrottle.RegisterFailure(accountKey)
+	handler.addressesThrottle.RegisterFailure(clientIP(r))
+}
+
+// resetLoginThrottle forgets the failed login attempts for the account
+// The client IP address failures are kept (they expire with the window), otherwise logging into another account
+// between the attempts would clear them
+func (handler *Handler) resetLoginThrottle(accountKey string) {
+	if handler.accountsThrottle == nil {
+		return
+	}
+
+	handler.accountsThrottle.Reset(accountKey)
+}
+
+// writeThrottled writes the error response for throttled login attempts
+func writeThrottled(w http.ResponseWriter, wait time.Duration, locked bool, subject string) {
+	// Informing the client how long it must wait (in seconds)
+	seconds := int(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+
+	if locked {
+		responses.Error(w, http.StatusTooManyRequests, fmt.Errorf(
+			"Too many failed login attempts, the %s is temporarily locked. Try again in %d seconds", subject, seconds,
+		))
+		return
+	}
+	responses.Error(w, http.StatusTooManyRequests, fmt.Errorf(
+		"Too many failed login attempts for the %s. Try again in %d seconds", subject, seconds,
+	))
+}
+
+// clientIP returns the IP address of the client making the request
+func clientIP(r *http.Request) string {
+	// When running behind a reverse proxy, the client address is provided on the headers
+	if config.TrustProxyHeaders {
+		if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+			return strings.TrimSpace(strings.Split(forwardedFor, ",")[0])
+		}
+		if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
+			return strings.TrimSpace(realIP)
+		}
+	}
+
+	// Otherwise, the connection address is used
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// CreatePersonalAccessToken creates a new personal access token for the user
+func (handler *Handler) CreatePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	// Getting the request parameters
+	params := mux.Vars(r)
+
+	// Getting the user ID
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Getting the user ID provided on the token
+	tokenUserID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// If user is trying to create a token for another user
+	if userID != tokenUserID {
+		responses.Error(w, http.StatusForbidden, errors.New("You cannot create tokens for another user"))
+		return
+	}
+
+	// Getting request body
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// Initializing the token, reading data from the request body
+	var token models.PersonalAccessToken
+	if err = json.Unmarshal(requestBody, &token); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Preparing token for insertion on database
+	if err := token.Prepare(); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	for _, scope := range token.Scopes {
+		if !authentication.IsValidScope(scope) {
+			responses.Error(w, http.StatusBadRequest, fmt.Errorf("Scope is not valid: %s", scope))
+			return
+		}
+	}
+
+	// Generating the token
+	token.UserID = userID
+	token.Token, token.TokenHash, err = authentication.CreatePersonalAccessToken()
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Creating the personal access tokens' repository
+	repository := repositories.NewPersonalAccessTokensRepository(handler.db)
+	// Storing the new token on the repository
+	token.ID, err = repository.Create(r.Context(), token)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// If everything is ok (this is the only time the token is returned)
+	responses.JSON(w, http.StatusCreated, token)
+}
+
+// SearchPersonalAccessTokens searchs all active personal access tokens from the user
+func (handler *Handler) SearchPersonalAccessTokens(w http.ResponseWriter, r *http.Request) {
+	// Getting the request parameters
+	params := mux.Vars(r)
+
+	// Getting the user ID
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Getting the user ID provided on the token
+	tokenUserID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// If user is trying to search another user's tokens
+	if userID != tokenUserID {
+		responses.Error(w, http.StatusForbidden, errors.New("You cannot search another user's tokens"))
+		return
+	}
+
+	// Creating the personal access tokens' repository
+	repository := repositories.NewPersonalAccessTokensRepository(handler.db)
+	// Searching tokens on the repository
+	tokens, err := repository.SearchByUser(r.Context(), userID)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Returning tokens response
+	responses.JSON(w, http.StatusOK, tokens)
+}
+
+// RevokePersonalAccessToken revokes a specific personal access token from the user
+func (handler *Handler) RevokePersonalAccessToken(w http.ResponseWriter, r *http.Request) {
+	// Getting the request parameters
+	params := mux.Vars(r)
+
+	// Getting the user ID
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Getting the token ID
+	tokenID, err := strconv.ParseUint(params["tokenId"], 10, 64)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Getting the user ID provided on the token
+	tokenUserID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	// If user is trying to revoke another user's token
+	if userID != tokenUserID {
+		responses.Error(w, http.StatusForbidden, errors.New("You cannot revoke another user's tokens"))
+		return
+	}
+
+	// Creating the personal access tokens' repository
+	repository := repositories.NewPersonalAccessTokensRepository(handler.db)
+	// Revoking the token on the repository
+	revoked, err := repository.Revoke(r.Context(), userID, tokenID)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !revoked {
+		responses.Error(w, http.StatusNotFound, errors.New("Token not found"))
+		return
+	}
+
+	// If everything is ok
+	responses.JSON(w, http.StatusNoContent, nil)
+}
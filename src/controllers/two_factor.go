@@ -0,0 +1,302 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"api/src/security"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// recoveryCodesQuantity defines how many recovery codes are generated when two-factor authentication is enabled
+const recoveryCodesQuantity = 10
+
+// EnrollTwoFactor generates a new TOTP secret for the user, which must be confirmed before being enabled
+func (handler *Handler) EnrollTwoFactor(w http.ResponseWriter, r *http.Request) {
+	// Getting the user ID from the request, checking if it's the authenticated user
+	userID, ok := twoFactorUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Getting the users' repository
+	repository := handler.users
+	// Searching user on the repository (its email identifies the account on authenticator apps)
+	user, err := repository.SearchByID(r.Context(), userID)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Generating the TOTP secret
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Storing the secret (encrypted), which is not enabled until a valid code is provided
+	encryptedSecret, err := security.EncryptTOTPSecret(secret)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	stored, err := repository.SetTwoFactorSecret(r.Context(), userID, encryptedSecret)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !stored {
+		responses.Error(w, http.StatusConflict, errors.New("Two-factor authentication is already enabled"))
+		return
+	}
+
+	// Returning the secret and the URI, used to set up authenticator apps
+	responses.JSON(w, http.StatusOK, models.TwoFactorEnrollment{
+		Secret: secret,
+		URI:    security.TOTPURI(config.TOTPIssuer, user.Email, secret),
+	})
+}
+
+// EnableTwoFactor checks a TOTP code for the enrolled secret and enables two-factor authentication
+func (handler *Handler) EnableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	// Getting the user ID from the request, checking if it's the authenticated user
+	userID, ok := twoFactorUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Getting request body
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// Initializing the code, reading data from the request body
+	var code models.TwoFactorCode
+	if err = json.Unmarshal(requestBody, &code); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Getting the users' repository
+	repository := handler.users
+	// Searching the user two-factor settings on the repository
+	twoFactor, err := repository.SearchTwoFactor(r.Context(), userID)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	if twoFactor.Enabled {
+		responses.Error(w, http.StatusConflict, errors.New("Two-factor authentication is already enabled"))
+		return
+	}
+	if twoFactor.Secret == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("Two-factor authentication must be enrolled first"))
+		return
+	}
+
+	// Checking the code against the enrolled secret
+	secret, err := security.DecryptTOTPSecret(twoFactor.Secret)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	step, valid := security.CheckTOTP(secret, code.Code, time.Now())
+	if !valid {
+		responses.Error(w, http.StatusUnauthorized, errors.New("Invalid two-factor code"))
+		return
+	}
+
+	// Generating the recovery codes (only their hashes are stored)
+	recoveryCodes, err := security.GenerateRecoveryCodes(recoveryCodesQuantity)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	codeHashes := make([]string, len(recoveryCodes))
+	for i, recoveryCode := range recoveryCodes {
+		// Hashing the codes like passwords, since they're short enough to be guessed from fast hashes
+		codeHash, err := security.Hash(recoveryCode)
+		if err != nil {
+			// If something goes wrong, we call the error response handling function
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+		codeHashes[i] = string(codeHash)
+	}
+
+	// Storing the recovery codes and enabling the two-factor authentication, in a unit of work
+	if !handler.runUnitOfWork(w, r, func(unit repositories.UnitOfWork) error {
+		if err := unit.RecoveryCodes.Replace(r.Context(), userID, codeHashes); err != nil {
+			return err
+		}
+		return unit.Users.EnableTwoFactor(r.Context(), userID, step)
+	}) {
+		return
+	}
+
+	// Returning the recovery codes (this is the only time they're returned)
+	responses.JSON(w, http.StatusOK, models.RecoveryCodes{RecoveryCodes: recoveryCodes})
+}
+
+// DisableTwoFactor disables two-factor authentication, after checking the user's current password
+func (handler *Handler) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	// Getting the user ID from the request, checking if it's the authenticated user
+	userID, ok := twoFactorUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Getting request body
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// Initializing the password, reading data from the request body
+	var password models.Password
+	if err = json.Unmarshal(requestBody, &password); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Getting the users' repository
+	repository := handler.users
+	// Checking if current password matches the user password
+	databaseHashPassword, err := repository.SearchPassword(r.Context(), userID)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err = security.CheckPassword(password.Current, databaseHashPassword); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusUnauthorized, errors.New("Incorrect current password"))
+		return
+	}
+
+	// Disabling the two-factor authentication and removing the recovery codes, in a unit of work
+	if !handler.runUnitOfWork(w, r, func(unit repositories.UnitOfWork) error {
+		if err := unit.Users.DisableTwoFactor(r.Context(), userID); err != nil {
+			return err
+		}
+		return unit.RecoveryCodes.DeleteByUser(r.Context(), userID)
+	}) {
+		return
+	}
+
+	// If everything is ok
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// twoFactorUserID gets the user ID from the request parameters, checking if it's the authenticated user
+// If it's not, the error response is written and false is returned
+func twoFactorUserID(w http.ResponseWriter, r *http.Request) (uint64, bool) {
+	// Getting the request parameters
+	params := mux.Vars(r)
+
+	// Getting the user ID
+	userID, err := strconv.ParseUint(params["userId"], 10, 64)
+	if err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return 0, false
+	}
+
+	// Getting the user ID provided on the token
+	tokenUserID, err := authentication.ExtractUserID(r)
+	if err != nil {
+		responses.Error(w, http.StatusUnauthorized, err)
+		return 0, false
+	}
+
+	// If user is trying to change another user's two-factor authentication
+	if userID != tokenUserID {
+		responses.Error(w, http.StatusForbidden, errors.New("You cannot change another user's two-factor authentication"))
+		return 0, false
+	}
+
+	return userID, true
+}
+
+// verifySecondFactor checks the TOTP code or recovery code provided on a two-factor login
+func (handler *Handler) verifySecondFactor(ctx context.Context, userID uint64, login models.TwoFactorLogin) (bool, error) {
+	// Recovery codes can be used only once
+	if login.RecoveryCode != "" {
+		return handler.useRecoveryCode(ctx, userID, security.NormalizeRecoveryCode(login.RecoveryCode))
+	}
+
+	// Searching the user two-factor settings on the repository
+	repository := handler.users
+	twoFactor, err := repository.SearchTwoFactor(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if !twoFactor.Enabled {
+		return false, nil
+	}
+
+	// Checking the TOTP code, which can't be used twice
+	secret, err := security.DecryptTOTPSecret(twoFactor.Secret)
+	if err != nil {
+		return false, err
+	}
+	step, valid := security.CheckTOTP(secret, login.Code, time.Now())
+	if !valid {
+		return false, nil
+	}
+	return repository.UseTwoFactorStep(ctx, userID, step)
+}
+
+// useRecoveryCode marks the user recovery code which matches the provided one as used
+// It returns false if no unused recovery code matches it
+func (handler *Handler) useRecoveryCode(ctx context.Context, userID uint64, code string) (bool, error) {
+	// Searching the unused recovery codes on the repository
+	repository := repositories.NewRecoveryCodesRepository(handler.db)
+	recoveryCodes, err := repository.SearchUnused(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	// Checking the code against each hash (they're salted, so they can't be searched directly)
+	for _, recoveryCode := range recoveryCodes {
+		if matchesRecoveryCode(code, recoveryCode.CodeHash) {
+			return repository.Use(ctx, recoveryCode.ID)
+		}
+	}
+	return false, nil
+}
+
+// matchesRecoveryCode checks if a recovery code matches a stored hash
+// Codes stored before they were hashed like passwords have unsalted SHA-256 hashes
+func matchesRecoveryCode(code, codeHash string) bool {
+	err := security.CheckPassword(code, codeHash)
+	if errors.Is(err, security.ErrUnsupportedHash) {
+		return subtle.ConstantTimeCompare([]byte(authentication.HashToken(code)), []byte(codeHash)) == 1
+	}
+	return err == nil
+}
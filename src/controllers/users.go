@@ -2,14 +2,15 @@ package controllers
 
 import (
 	"api/src/authentication"
-	"api/src/database"
 	"api/src/models"
 	"api/src/repositories"
 	"api/src/responses"
 	"api/src/security"
+	"context"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -18,7 +19,7 @@ import (
 )
 
 // CreateUser inserts a new user on the database
-func CreateUser(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	// Getting request body
 	requestBody, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -42,47 +43,51 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
-	if err != nil {
+	// Checking the password (against the already formatted username and email), and creating its hash
+	if !checkPasswordPolicy(w, user.Pass, user.Username, user.Email) {
+		return
+	}
+	if err = user.HashPassword(); err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer db.Close()
 
-	// Creating the users' repository
-	repository := repositories.NewUsersRepository(db)
+	// Getting the users' repository
+	repository := handler.users
 	// Creating a new user on the repository
-	user.ID, err = repository.Create(user)
+	user.ID, err = repository.Create(r.Context(), user)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	// Sending the email verification link (the user can ask for a new one if it fails)
+	if err = handler.sendVerificationEmail(user); err != nil {
+		log.Printf("Error sending verification email to user %d: %v", user.ID, err)
+	}
+
 	// If everything is ok
 	responses.JSON(w, http.StatusCreated, user)
 }
 
 // SearchUsers searchs all users from the database
-func SearchUsers(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 	// Getting the name or username to be used while filtering users on database
 	nameOrUsername := strings.ToLower(r.URL.Query().Get(("user")))
 
-	// Connecting to the database
-	db, err := database.Connect()
+	// Getting the requested page
+	page, err := readPageRequest(r)
 	if err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+		responses.Error(w, http.StatusBadRequest, err)
 		return
 	}
-	defer db.Close()
 
-	// Creating the users' repository
-	repository := repositories.NewUsersRepository(db)
+	// Getting the users' repository
+	repository := handler.users
 	// Searching users on the repository
-	users, err := repository.Search(nameOrUsername)
+	users, more, err := repository.Search(r.Context(), nameOrUsername, page)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
@@ -90,11 +95,11 @@ func SearchUsers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Returning users response
-	responses.JSON(w, http.StatusOK, users)
+	responses.JSON(w, http.StatusOK, newPage(users, more, page, userKey))
 }
 
 // SearchUser search a specific user from the database
-func SearchUser(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) SearchUser(w http.ResponseWriter, r *http.Request) {
 	// Getting the request parameters
 	params := mux.Vars(r)
 
@@ -105,19 +110,10 @@ func SearchUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
-	if err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
-		return
-	}
-	defer db.Close()
-
-	// Creating the users' repository
-	repository := repositories.NewUsersRepository(db)
+	// Getting the users' repository
+	repository := handler.users
 	// Searching user on the repository
-	user, err := repository.SearchByID(userID)
+	user, err := repository.SearchByID(r.Context(), userID)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
@@ -129,7 +125,7 @@ func SearchUser(w http.ResponseWriter, r *http.Request) {
 }
 
 // UpdateUser updates a specific user on the database
-func UpdateUser(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	// Getting the request parameters
 	params := mux.Vars(r)
 
@@ -176,30 +172,36 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
+	// Getting the users' repository
+	repository := handler.users
+	// Searching the current user data, to check if the email is changing
+	currentUser, err := repository.SearchByID(r.Context(), userID)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer db.Close()
-
-	// Creating the users' repository
-	repository := repositories.NewUsersRepository(db)
 	// Updating an existing user on the repository
-	if err = repository.Update(userID, user); err != nil {
+	if err = repository.Update(r.Context(), userID, user); err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
 
+	// A new email must be verified again
+	if user.Email != currentUser.Email {
+		user.ID = userID
+		if err = handler.sendVerificationEmail(user); err != nil {
+			log.Printf("Error sending verification email to user %d: %v", user.ID, err)
+		}
+	}
+
 	// If everything is ok
 	responses.JSON(w, http.StatusNoContent, nil)
 }
 
 // DeletehUser removes a specific user from the database
-func DeleteUser(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	// Getting the request parameters
 	params := mux.Vars(r)
 
@@ -210,43 +212,110 @@ func DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Getting the user ID provided on the token
-	tokenUserID, err := authentication.ExtractUserID(r)
+	// Getting the claims provided on the token
+	claims, err := authentication.ExtractClaims(r)
 	if err != nil {
 		responses.Error(w, http.StatusUnauthorized, err)
 		return
 	}
 
-	// If user is trying to delete another user
-	if userID != tokenUserID {
+	// If user is trying to delete another user (only allowed for admins)
+	if userID != claims.UserID && !claims.HasPermission(authentication.PermissionManageUsers) {
 		responses.Error(w, http.StatusForbidden, errors.New("You cannot delete another user's data"))
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
+	// Invalidating the user tokens and deleting the user (it's kept until purged, so it can be restored)
+	if !handler.runUnitOfWork(w, r, func(unit repositories.UnitOfWork) error {
+		if err := invalidateUserTokens(r.Context(), unit, userID); err != nil {
+			return err
+		}
+		return unit.Users.Delete(r.Context(), userID)
+	}) {
+		return
+	}
+
+	// If everything is ok
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// RestoreAccount brings back the deleted account of the user providing its credentials (before it's purged)
+// The user must log in again afterwards, since its tokens were invalidated when the account was deleted
+func (handler *Handler) RestoreAccount(w http.ResponseWriter, r *http.Request) {
+	// Getting request body
+	requestBody, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// Initializing the credentials, reading data from the request body
+	var credentials models.Credentials
+	if err = json.Unmarshal(requestBody, &credentials); err != nil {
+		// If something goes wrong, we call the error response handling function
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+	if err = credentials.Prepare(); err != nil {
+		responses.Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Checking if attempts for the account are allowed (failed attempts are throttled along with the login ones)
+	accountKey := "login:" + strings.ToLower(credentials.Login)
+	if !handler.checkLoginThrottle(w, r, accountKey) {
+		return
+	}
+
+	// Getting the users' repository
+	repository := handler.users
+	// Searching the deleted user on the repository, by its email or username
+	var deletedUser models.User
+	if credentials.IsEmail() {
+		deletedUser, err = repository.SearchDeletedByEmail(r.Context(), credentials.Login)
+	} else {
+		deletedUser, err = repository.SearchDeletedByUsername(r.Context(), credentials.Login)
+	}
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
-	defer db.Close()
 
-	// Creating the users' repository
-	repository := repositories.NewUsersRepository(db)
-	// Deleting an existing user from the repository
-	if err = repository.Delete(userID); err != nil {
+	// Checking if password is correct (unknown users are handled like on login, so the response time is similar)
+	passwordHash := deletedUser.Pass
+	if deletedUser.ID == 0 {
+		passwordHash = dummyPasswordHash()
+	}
+	if err = security.CheckPassword(credentials.Password, passwordHash); err != nil || deletedUser.ID == 0 {
+		// Registering the failed attempt
+		handler.registerLoginFailure(r, accountKey)
+		responses.Error(w, http.StatusUnauthorized, errors.New("Invalid login or password"))
+		return
+	}
+
+	// Forgetting previous failed attempts
+	handler.resetLoginThrottle(accountKey)
+
+	// Restoring the user
+	restored, err := repository.Restore(r.Context(), deletedUser.ID)
+	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
 	}
+	if !restored {
+		responses.Error(w, http.StatusConflict, errors.New("The account has already been restored"))
+		return
+	}
 
 	// If everything is ok
 	responses.JSON(w, http.StatusNoContent, nil)
 }
 
 // FollowUser allows an user to follow another one
-func FollowUser(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) FollowUser(w http.ResponseWriter, r *http.Request) {
 	// Getting the follower ID provided on the token
 	followerID, err := authentication.ExtractUserID(r)
 	if err != nil {
@@ -270,19 +339,10 @@ func FollowUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
-	if err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
-		return
-	}
-	defer db.Close()
-
-	// Creating the users' repository
-	repository := repositories.NewUsersRepository(db)
+	// Getting the users' repository
+	repository := handler.users
 	// Following an existing user on the repository
-	if err = repository.Follow(userID, followerID); err != nil {
+	if err = repository.Follow(r.Context(), userID, followerID); err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
@@ -293,7 +353,7 @@ func FollowUser(w http.ResponseWriter, r *http.Request) {
 }
 
 // UnfollowUser allows an user to stop following another one
-func UnfollowUser(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) UnfollowUser(w http.ResponseWriter, r *http.Request) {
 	// Getting the follower ID provided on the token
 	followerID, err := authentication.ExtractUserID(r)
 	if err != nil {
@@ -317,19 +377,10 @@ func UnfollowUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
-	if err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
-		return
-	}
-	defer db.Close()
-
-	// Creating the users' repository
-	repository := repositories.NewUsersRepository(db)
+	// Getting the users' repository
+	repository := handler.users
 	// Unfollowing an existing user on the repository
-	if err = repository.Unfollow(userID, followerID); err != nil {
+	if err = repository.Unfollow(r.Context(), userID, followerID); err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
 		return
@@ -340,7 +391,7 @@ func UnfollowUser(w http.ResponseWriter, r *http.Request) {
 }
 
 // SearchFollowers searchs all followers from an user
-func SearchFollowers(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) SearchFollowers(w http.ResponseWriter, r *http.Request) {
 	// Getting the request parameters
 	params := mux.Vars(r)
 
@@ -351,19 +402,17 @@ func SearchFollowers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
+	// Getting the requested page
+	page, err := readPageRequest(r)
 	if err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+		responses.Error(w, http.StatusBadRequest, err)
 		return
 	}
-	defer db.Close()
 
-	// Creating the users' repository
-	repository := repositories.NewUsersRepository(db)
+	// Getting the users' repository
+	repository := handler.users
 	// Searching followers on the repository
-	followers, err := repository.SearchFollowers(userID)
+	followers, more, err := repository.SearchFollowers(r.Context(), userID, page)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
@@ -371,11 +420,11 @@ func SearchFollowers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Returning followers response
-	responses.JSON(w, http.StatusOK, followers)
+	responses.JSON(w, http.StatusOK, newPage(followers, more, page, userKey))
 }
 
 // SearchFollowing searchs all users followed by another one
-func SearchFollowing(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) SearchFollowing(w http.ResponseWriter, r *http.Request) {
 	// Getting the request parameters
 	params := mux.Vars(r)
 
@@ -386,19 +435,17 @@ func SearchFollowing(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
+	// Getting the requested page
+	page, err := readPageRequest(r)
 	if err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+		responses.Error(w, http.StatusBadRequest, err)
 		return
 	}
-	defer db.Close()
 
-	// Creating the users' repository
-	repository := repositories.NewUsersRepository(db)
+	// Getting the users' repository
+	repository := handler.users
 	// Searching users on the repository
-	users, err := repository.SearchFollowing(userID)
+	users, more, err := repository.SearchFollowing(r.Context(), userID, page)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
@@ -406,11 +453,11 @@ func SearchFollowing(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Returning users response
-	responses.JSON(w, http.StatusOK, users)
+	responses.JSON(w, http.StatusOK, newPage(users, more, page, userKey))
 }
 
 // ChangePassword updates a specific user password on the database
-func ChangePassword(w http.ResponseWriter, r *http.Request) {
+func (handler *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	// Getting the request parameters
 	params := mux.Vars(r)
 
@@ -450,19 +497,10 @@ func ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connecting to the database
-	db, err := database.Connect()
-	if err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
-		return
-	}
-	defer db.Close()
-
-	// Creating the users' repository
-	repository := repositories.NewUsersRepository(db)
+	// Getting the users' repository
+	repository := handler.users
 	// Checking if current password matches the user password
-	databaseHashPassword, err := repository.SearchPassword(userID)
+	databaseHashPassword, err := repository.SearchPassword(r.Context(), userID)
 	if err != nil {
 		// If something goes wrong, we call the error response handling function
 		responses.Error(w, http.StatusInternalServerError, err)
@@ -474,6 +512,11 @@ func ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Checking if the new password follows the password policy
+	if !validateNewPassword(r.Context(), w, repository, userID, password.New) {
+		return
+	}
+
 	// Creating the new password hash
 	hashPassword, err := security.Hash(password.New)
 	if err != nil {
@@ -482,13 +525,73 @@ func ChangePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Changing user's password
-	if err = repository.ChangePassword(userID, string(hashPassword)); err != nil {
-		// If something goes wrong, we call the error response handling function
-		responses.Error(w, http.StatusInternalServerError, err)
+	// Changing user's password and invalidating the tokens issued with the old one, in a unit of work
+	if !handler.runUnitOfWork(w, r, func(unit repositories.UnitOfWork) error {
+		// The password is only replaced if it wasn't changed since it was checked (e.g. by a concurrent request)
+		replaced, err := unit.Users.ReplacePassword(r.Context(), userID, databaseHashPassword, string(hashPassword))
+		if err != nil {
+			return err
+		}
+		if !replaced {
+			return &statusError{http.StatusConflict, errors.New("The password was changed by another request, please try again")}
+		}
+
+		return invalidateUserTokens(r.Context(), unit, userID)
+	}) {
 		return
 	}
 
 	// If everything is ok
 	responses.JSON(w, http.StatusNoContent, nil)
 }
+
+// validateNewPassword checks if a new password follows the password policy (it can't contain the user's personal data)
+// If it doesn't, the error response is written and false is returned
+func validateNewPassword(ctx context.Context, w http.ResponseWriter, repository repositories.UserStore, userID uint64, password string) bool {
+	// Searching user on the repository
+	user, err := repository.SearchByID(ctx, userID)
+	if err != nil {
+		responses.Error(w, http.StatusInternalServerError, err)
+		return false
+	}
+
+	// Checking the password, reporting the rule which failed
+	return checkPasswordPolicy(w, password, user.Username, user.Email)
+}
+
+// checkPasswordPolicy checks if a new password follows the password policy, responding with the rule which failed
+// (or with an internal error, if the check itself failed). If it returns false, the handler must stop
+func checkPasswordPolicy(w http.ResponseWriter, password, username, email string) bool {
+	if err := security.ValidatePassword(password, username, email); err != nil {
+		var policyErr *security.PasswordPolicyError
+		if errors.As(err, &policyErr) {
+			responses.Error(w, http.StatusBadRequest, err)
+		} else {
+			responses.Error(w, http.StatusInternalServerError, err)
+		}
+		return false
+	}
+	return true
+}
+
+// invalidateUserTokens makes all tokens issued to an user (access, refresh and personal access tokens) stop being accepted
+// It runs in the unit of work of the change which requires it, so the tokens are only invalidated if the change is saved
+func invalidateUserTokens(ctx context.Context, unit repositories.UnitOfWork, userID uint64) error {
+	// Revoking all user refresh tokens, so no new access tokens can be issued
+	if err := unit.RefreshTokens.RevokeByUser(ctx, userID); err != nil {
+		return err
+	}
+
+	// Revoking all user personal access tokens
+	if err := unit.PersonalAccessTokens.RevokeByUser(ctx, userID); err != nil {
+		return err
+	}
+
+	// Ending all user sessions
+	if err := unit.Sessions.RevokeByUser(ctx, userID); err != nil {
+		return err
+	}
+
+	// Incrementing the user token version, so previous access tokens are rejected
+	return unit.Users.IncrementTokenVersion(ctx, userID)
+}
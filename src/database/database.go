@@ -4,19 +4,34 @@ import (
 	"api/src/config"
 	"database/sql"
 
+	_ "github.com/glebarez/go-sqlite"  // SQLite Driver
 	_ "github.com/go-sql-driver/mysql" // MySQL Driver
+	_ "github.com/lib/pq"              // PostgreSQL Driver
 )
 
-// Connect establishes a connection to the database
-func Connect() (*sql.DB, error) {
+// Connect establishes a connection pool to the database, with the configured driver and settings
+// The pool must be created once and shared, since it's safe for concurrent use
+func Connect() (*DB, error) {
+	// Getting the dialect from the configured driver
+	dialect, err := DialectFor(config.DbDriver)
+	if err != nil {
+		return nil, err
+	}
+
 	// Connecting to the database
-	db, err := sql.Open("mysql", config.DbConnString)
+	db, err := sql.Open(dialect.Driver(), config.DbConnString)
 
 	// If an error occurs during the connection
 	if err != nil {
 		return nil, err
 	}
 
+	// Setting the connection pool limits
+	db.SetMaxOpenConns(config.DbMaxOpenConns)
+	db.SetMaxIdleConns(config.DbMaxIdleConns)
+	db.SetConnMaxLifetime(config.DbConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.DbConnMaxIdleTime)
+
 	// If it wasn't possible to communicate with the database
 	if err = db.Ping(); err != nil {
 		db.Close()
@@ -24,6 +39,6 @@ func Connect() (*sql.DB, error) {
 	}
 
 	// Returning the connection
-	return db, nil
+	return NewDB(db, dialect, config.DbQueryTimeout), nil
 
 }
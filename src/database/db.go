@@ -0,0 +1,262 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Executor runs queries adapted to a dialect, either directly on the connection pool (DB) or inside a transaction (Tx)
+// Every query is bound to a context, and limited by the default query timeout (if there's one)
+type Executor interface {
+	Dialect() Dialect
+	PrepareContext(ctx context.Context, query string) (*Stmt, error)
+	PrepareInsertContext(ctx context.Context, query string) (*Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row
+}
+
+// runner is implemented by both *sql.DB and *sql.Tx
+type runner interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// queries implements the Executor methods, for both the connection pool and the transactions
+type queries struct {
+	runner  runner
+	dialect Dialect
+	timeout time.Duration
+}
+
+// DB is a database connection pool which adapts the queries to its dialect
+// It's safe for concurrent use, like the underlying *sql.DB
+type DB struct {
+	queries
+	pool *sql.DB
+}
+
+// Tx is a database transaction which adapts the queries to its dialect
+// Transactions are created by DB.Transaction, which commits or rolls them back
+type Tx struct {
+	queries
+}
+
+// Stmt is a prepared statement which adapts the arguments to its dialect
+type Stmt struct {
+	statement *sql.Stmt
+	dialect   Dialect
+	timeout   time.Duration
+	returning bool
+}
+
+// Rows is the result of a query, which releases the query context when it's closed
+type Rows struct {
+	*sql.Rows
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Row is the result of a query which returns at most one row
+type Row struct {
+	row    *sql.Row
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewDB wraps an already opened connection pool, which uses the provided dialect
+// Queries taking longer than the timeout are cancelled (zero means no default timeout)
+func NewDB(db *sql.DB, dialect Dialect, timeout time.Duration) *DB {
+	return &DB{queries{db, dialect, timeout}, db}
+}
+
+// Close closes the connection pool
+func (db *DB) Close() error {
+	return db.pool.Close()
+}
+
+// Transaction runs a function inside a transaction, which is committed if the function succeeds
+// If the function returns an error (or panics), the transaction is rolled back
+func (db *DB) Transaction(ctx context.Context, function func(tx *Tx) error) (err error) {
+	sqlTx, err := db.pool.BeginTx(ctx, nil)
+	if err != nil {
+		return contextError(ctx, err)
+	}
+	tx := &Tx{queries{sqlTx, db.dialect, db.timeout}}
+
+	// Rolling back the transaction unless it's committed (a panic is propagated after the rollback)
+	committed := false
+	defer func() {
+		if !committed {
+			sqlTx.Rollback()
+		}
+	}()
+
+	if err = function(tx); err != nil {
+		return err
+	}
+	if err = sqlTx.Commit(); err != nil {
+		return contextError(ctx, err)
+	}
+	committed = true
+	return nil
+}
+
+// Dialect returns the dialect used by the database
+func (queries *queries) Dialect() Dialect {
+	return queries.dialect
+}
+
+// PrepareContext creates a prepared statement for later queries or executions
+func (queries *queries) PrepareContext(ctx context.Context, query string) (*Stmt, error) {
+	return queries.prepare(ctx, queries.dialect.Rebind(query), false)
+}
+
+// PrepareInsertContext creates a prepared insert statement, whose InsertContext method returns the inserted ID
+// The table primary key must be the "id" column
+func (queries *queries) PrepareInsertContext(ctx context.Context, query string) (*Stmt, error) {
+	returning := queries.dialect.ReturningID()
+	if returning {
+		query += " returning id"
+	}
+	return queries.prepare(ctx, queries.dialect.Rebind(query), returning)
+}
+
+// ExecContext executes a query without returning any rows
+func (queries *queries) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := withTimeout(ctx, queries.timeout)
+	defer cancel()
+
+	result, err := queries.runner.ExecContext(ctx, queries.dialect.Rebind(query), values(queries.dialect, args)...)
+	return result, contextError(ctx, err)
+}
+
+// QueryContext executes a query which returns rows
+func (queries *queries) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	ctx, cancel := withTimeout(ctx, queries.timeout)
+	rows, err := queries.runner.QueryContext(ctx, queries.dialect.Rebind(query), values(queries.dialect, args)...)
+	if err != nil {
+		cancel()
+		return nil, contextError(ctx, err)
+	}
+	return &Rows{rows, ctx, cancel}, nil
+}
+
+// QueryRowContext executes a query which is expected to return at most one row
+func (queries *queries) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	ctx, cancel := withTimeout(ctx, queries.timeout)
+	return &Row{queries.runner.QueryRowContext(ctx, queries.dialect.Rebind(query), values(queries.dialect, args)...), ctx, cancel}
+}
+
+// prepare creates a prepared statement for an already rebound query
+func (queries *queries) prepare(ctx context.Context, query string, returning bool) (*Stmt, error) {
+	prepareCtx, cancel := withTimeout(ctx, queries.timeout)
+	defer cancel()
+
+	statement, err := queries.runner.PrepareContext(prepareCtx, query)
+	if err != nil {
+		return nil, contextError(prepareCtx, err)
+	}
+	return &Stmt{statement, queries.dialect, queries.timeout, returning}, nil
+}
+
+// Close closes the prepared statement
+func (statement *Stmt) Close() error {
+	return statement.statement.Close()
+}
+
+// ExecContext executes the prepared statement without returning any rows
+func (statement *Stmt) ExecContext(ctx context.Context, args ...interface{}) (sql.Result, error) {
+	ctx, cancel := withTimeout(ctx, statement.timeout)
+	defer cancel()
+
+	result, err := statement.statement.ExecContext(ctx, values(statement.dialect, args)...)
+	return result, contextError(ctx, err)
+}
+
+// QueryContext executes the prepared statement, returning its rows
+func (statement *Stmt) QueryContext(ctx context.Context, args ...interface{}) (*Rows, error) {
+	ctx, cancel := withTimeout(ctx, statement.timeout)
+	rows, err := statement.statement.QueryContext(ctx, values(statement.dialect, args)...)
+	if err != nil {
+		cancel()
+		return nil, contextError(ctx, err)
+	}
+	return &Rows{rows, ctx, cancel}, nil
+}
+
+// QueryRowContext executes the prepared statement, which is expected to return at most one row
+func (statement *Stmt) QueryRowContext(ctx context.Context, args ...interface{}) *Row {
+	ctx, cancel := withTimeout(ctx, statement.timeout)
+	return &Row{statement.statement.QueryRowContext(ctx, values(statement.dialect, args)...), ctx, cancel}
+}
+
+// InsertContext executes a statement created by PrepareInsertContext, returning the inserted ID
+func (statement *Stmt) InsertContext(ctx context.Context, args ...interface{}) (uint64, error) {
+	// Reading the ID from the returning clause
+	if statement.returning {
+		var ID uint64
+		if err := statement.QueryRowContext(ctx, args...).Scan(&ID); err != nil {
+			return 0, err
+		}
+		return ID, nil
+	}
+
+	// Getting the last inserted ID from the driver
+	result, err := statement.ExecContext(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+	lastInsertedID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(lastInsertedID), nil
+}
+
+// Err returns the error found while reading the rows, if any
+func (rows *Rows) Err() error {
+	return contextError(rows.ctx, rows.Rows.Err())
+}
+
+// Close closes the rows and releases the query context
+func (rows *Rows) Close() error {
+	defer rows.cancel()
+	return rows.Rows.Close()
+}
+
+// Scan copies the row columns into the destinations, and releases the query context
+// It returns sql.ErrNoRows if the query didn't return any rows
+func (row *Row) Scan(dest ...interface{}) error {
+	defer row.cancel()
+	return contextError(row.ctx, row.row.Scan(dest...))
+}
+
+// withTimeout returns a copy of the context limited by the timeout (if there's one)
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// contextError replaces the driver error by the context error (context.Canceled or context.DeadlineExceeded)
+// when the query failed because its context was done, since drivers report it in different ways
+func contextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// values converts the query arguments to the format expected by the dialect
+func values(dialect Dialect, args []interface{}) []interface{} {
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		converted[i] = dialect.Value(arg)
+	}
+	return converted
+}
@@ -0,0 +1,341 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect adapts the queries to a database engine
+// Queries are written with "?" placeholders and the SQL supported by all engines, and the dialect
+// provides the statements which differ between them (e.g. ignoring duplicated entries)
+type Dialect interface {
+	// Driver returns the name of the database/sql driver
+	Driver() string
+	// Rebind replaces the "?" placeholders with the ones used by the engine
+	Rebind(query string) string
+	// InsertIgnore turns an insert statement into one which ignores duplicated entries
+	InsertIgnore(query string) string
+	// Upsert appends to an insert statement the clause which updates the columns when the key already exists
+	Upsert(query string, key []string, columns ...string) string
+	// ReturningID tells whether inserted IDs are read with a returning clause, instead of the driver last insert ID
+	ReturningID() bool
+	// Value converts a query argument to the format expected by the engine
+	Value(arg interface{}) interface{}
+	// ForUpdate returns the clause which locks the rows read by a select statement until the transaction ends
+	ForUpdate() string
+	// TimestampType returns the column type used to store times
+	TimestampType() string
+	// FullTextSearch returns the clauses which match the rows of a table (with an alias) to full-text terms, using
+	// the full-text index on its columns (see the migrations): the join to be appended to the from clause (it may be
+	// empty), the condition, and the relevance expression (greater is more relevant) to be used on the order by clause
+	// The arguments match the condition followed by the relevance expression
+	FullTextSearch(table, alias string, columns []string, terms []FullTextTerm) (string, string, string, []interface{})
+	// LockMigrations takes the lock which prevents concurrent migration runners, waiting up to the timeout
+	// The lock belongs to the connection, which must be used to apply the migrations
+	LockMigrations(conn *sql.Conn, timeout time.Duration) error
+	// UnlockMigrations releases the lock taken by LockMigrations
+	UnlockMigrations(conn *sql.Conn) error
+	// LockIsTransaction tells whether the migrations lock is a transaction, in which case
+	// each migration runs in a savepoint instead of its own transaction
+	LockIsTransaction() bool
+}
+
+// FullTextTerm is a term of a full-text query, which must be found on the matching rows: either a word,
+// a phrase (several words in sequence) or a prefix (a single word which the matching words start with)
+// The words may only have letters and digits, so they're never mistaken for the engines query syntax
+type FullTextTerm struct {
+	Words  []string
+	Prefix bool
+}
+
+// ErrMigrationsLocked is returned when the migrations lock couldn't be taken before the timeout
+var ErrMigrationsLocked = errors.New("Migrations are locked by another runner")
+
+// migrationsLockKey identifies the migrations lock, on databases which use named locks
+const migrationsLockKey = "devbook_schema_migrations"
+
+// migrationsLockID identifies the migrations lock on PostgreSQL, whose advisory locks are numbered
+const migrationsLockID = 4815162342
+
+// DialectFor returns the dialect used by a specific driver ("mysql", "postgres" or "sqlite")
+func DialectFor(driver string) (Dialect, error) {
+	switch driver {
+	case "mysql":
+		return mysql{}, nil
+	case "postgres":
+		return postgres{}, nil
+	case "sqlite":
+		return sqlite{}, nil
+	}
+	return nil, fmt.Errorf("Unsupported database driver! %s", driver)
+}
+
+// mysql is the MySQL/MariaDB dialect
+type mysql struct{}
+
+func (mysql) Driver() string { return "mysql" }
+
+func (mysql) Rebind(query string) string { return query }
+
+func (mysql) InsertIgnore(query string) string {
+	return "insert ignore" + strings.TrimSpace(query)[len("insert"):]
+}
+
+func (mysql) Upsert(query string, key []string, columns ...string) string {
+	updates := make([]string, len(columns))
+	for i, column := range columns {
+		updates[i] = fmt.Sprintf("%s = values(%s)", column, column)
+	}
+	return query + " on duplicate key update " + strings.Join(updates, ", ")
+}
+
+func (mysql) ReturningID() bool { return false }
+
+func (mysql) Value(arg interface{}) interface{} { return arg }
+
+func (mysql) ForUpdate() string { return " for update" }
+
+func (mysql) TimestampType() string { return "datetime" }
+
+// FullTextSearch matches the terms in boolean mode, where each one is required (+), and prefixes end with "*"
+func (mysql) FullTextSearch(table, alias string, columns []string, terms []FullTextTerm) (string, string, string, []interface{}) {
+	query := make([]string, len(terms))
+	for i, term := range terms {
+		switch {
+		case term.Prefix:
+			query[i] = "+" + term.Words[0] + "*"
+		case len(term.Words) > 1:
+			query[i] = `+"` + strings.Join(term.Words, " ") + `"`
+		default:
+			query[i] = "+" + term.Words[0]
+		}
+	}
+
+	match := fmt.Sprintf("match(%s) against (? in boolean mode)", qualify(alias, columns, ", "))
+	value := strings.Join(query, " ")
+	return "", match, match, []interface{}{value, value}
+}
+
+func (mysql) LockMigrations(conn *sql.Conn, timeout time.Duration) error {
+	// The lock name is prefixed with the database name, since MySQL named locks are shared by the whole server
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(
+		context.Background(), "select get_lock(concat(database(), '.', ?), ?)", migrationsLockKey, int(timeout.Seconds()),
+	).Scan(&locked); err != nil {
+		return err
+	}
+	if locked.Int64 != 1 {
+		return ErrMigrationsLocked
+	}
+	return nil
+}
+
+func (mysql) UnlockMigrations(conn *sql.Conn) error {
+	_, err := conn.ExecContext(context.Background(), "do release_lock(concat(database(), '.', ?))", migrationsLockKey)
+	return err
+}
+
+func (mysql) LockIsTransaction() bool { return false }
+
+// postgres is the PostgreSQL dialect, which uses numbered placeholders ($1, $2...)
+type postgres struct{}
+
+func (postgres) Driver() string { return "postgres" }
+
+func (postgres) Rebind(query string) string {
+	var builder strings.Builder
+	builder.Grow(len(query) + 10)
+
+	// Replacing the placeholders, except the ones inside quoted strings
+	position, quoted := 0, false
+	for _, char := range query {
+		switch {
+		case char == '\'':
+			quoted = !quoted
+		case char == '?' && !quoted:
+			position++
+			builder.WriteString("$" + strconv.Itoa(position))
+			continue
+		}
+		builder.WriteRune(char)
+	}
+	return builder.String()
+}
+
+func (postgres) InsertIgnore(query string) string {
+	return query + " on conflict do nothing"
+}
+
+func (postgres) Upsert(query string, key []string, columns ...string) string {
+	return onConflictUpdate(query, key, columns)
+}
+
+func (postgres) ReturningID() bool { return true }
+
+func (postgres) Value(arg interface{}) interface{} { return arg }
+
+func (postgres) ForUpdate() string { return " for update" }
+
+func (postgres) TimestampType() string { return "timestamptz" }
+
+// FullTextSearch matches the terms on the "simple" text search configuration (with no stemming or stop words),
+// which must be the one used by the expression index. Phrases are words followed by each other (<->),
+// and prefixes end with ":*"
+func (postgres) FullTextSearch(table, alias string, columns []string, terms []FullTextTerm) (string, string, string, []interface{}) {
+	query := make([]string, len(terms))
+	for i, term := range terms {
+		words := make([]string, len(term.Words))
+		for j, word := range term.Words {
+			words[j] = "'" + word + "'"
+		}
+		query[i] = strings.Join(words, " <-> ")
+		if term.Prefix {
+			query[i] += ":*"
+		}
+	}
+
+	document := fmt.Sprintf("to_tsvector('simple', %s)", qualify(alias, columns, " || ' ' || "))
+	value := strings.Join(query, " & ")
+	return "",
+		document + " @@ to_tsquery('simple', ?)",
+		"ts_rank(" + document + ", to_tsquery('simple', ?))",
+		[]interface{}{value, value}
+}
+
+func (postgres) LockMigrations(conn *sql.Conn, timeout time.Duration) error {
+	// Trying to take the advisory lock until the timeout, since waiting for it can't be limited
+	deadline := time.Now().Add(timeout)
+	for {
+		var locked bool
+		if err := conn.QueryRowContext(
+			context.Background(), "select pg_try_advisory_lock($1)", migrationsLockID,
+		).Scan(&locked); err != nil {
+			return err
+		}
+		if locked {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return ErrMigrationsLocked
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func (postgres) UnlockMigrations(conn *sql.Conn) error {
+	_, err := conn.ExecContext(context.Background(), "select pg_advisory_unlock($1)", migrationsLockID)
+	return err
+}
+
+func (postgres) LockIsTransaction() bool { return false }
+
+// sqlite is the SQLite dialect (embedded database, with no external server)
+type sqlite struct{}
+
+func (sqlite) Driver() string { return "sqlite" }
+
+func (sqlite) Rebind(query string) string { return query }
+
+func (sqlite) InsertIgnore(query string) string {
+	return query + " on conflict do nothing"
+}
+
+func (sqlite) Upsert(query string, key []string, columns ...string) string {
+	return onConflictUpdate(query, key, columns)
+}
+
+func (sqlite) ReturningID() bool { return false }
+
+// Value stores times in UTC, since SQLite compares them as text and the offsets must match
+func (sqlite) Value(arg interface{}) interface{} {
+	switch value := arg.(type) {
+	case time.Time:
+		return value.UTC()
+	case *time.Time:
+		if value != nil {
+			return value.UTC()
+		}
+	}
+	return arg
+}
+
+// ForUpdate returns an empty clause, since SQLite doesn't lock rows: transactions are started with
+// "begin immediate" (see the _txlock connection parameter), so they already hold the database write lock
+func (sqlite) ForUpdate() string { return "" }
+
+func (sqlite) TimestampType() string { return "datetime" }
+
+// FullTextSearch matches the terms on the FTS5 table which indexes the table columns (named after the table,
+// with the "_search" suffix). Phrases and words are quoted, and prefixes end with "*"
+// The relevance is the opposite of the bm25 rank, which is lower for the best matches
+func (sqlite) FullTextSearch(table, alias string, columns []string, terms []FullTextTerm) (string, string, string, []interface{}) {
+	query := make([]string, len(terms))
+	for i, term := range terms {
+		query[i] = `"` + strings.Join(term.Words, " ") + `"`
+		if term.Prefix {
+			query[i] += "*"
+		}
+	}
+
+	index := table + "_search"
+	return fmt.Sprintf(" inner join %s on %s.rowid = %s.id", index, index, alias),
+		index + " match ?",
+		"-bm25(" + index + ")",
+		[]interface{}{strings.Join(query, " AND ")}
+}
+
+// LockMigrations starts a write transaction, which is kept until UnlockMigrations is called
+// Other connections (from any process) may still read the database, but their writes wait for the lock
+func (sqlite) LockMigrations(conn *sql.Conn, timeout time.Duration) error {
+	// Starting the transaction waits for other writers according to the connection busy timeout,
+	// so it's retried until the timeout
+	deadline := time.Now().Add(timeout)
+	for {
+		_, err := conn.ExecContext(context.Background(), "begin immediate")
+		if err == nil {
+			return nil
+		}
+		if !isBusy(err) {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return ErrMigrationsLocked
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+func (sqlite) UnlockMigrations(conn *sql.Conn) error {
+	_, err := conn.ExecContext(context.Background(), "commit")
+	return err
+}
+
+func (sqlite) LockIsTransaction() bool { return true }
+
+// isBusy checks if an SQLite error was caused by a lock held by another connection
+func isBusy(err error) bool {
+	return strings.Contains(err.Error(), "SQLITE_BUSY") || strings.Contains(err.Error(), "database is locked")
+}
+
+// qualify prefixes the columns with a table alias, joining them with the separator
+func qualify(alias string, columns []string, separator string) string {
+	qualified := make([]string, len(columns))
+	for i, column := range columns {
+		qualified[i] = alias + "." + column
+	}
+	return strings.Join(qualified, separator)
+}
+
+// onConflictUpdate appends the standard SQL upsert clause (used by PostgreSQL and SQLite) to an insert statement
+func onConflictUpdate(query string, key []string, columns []string) string {
+	updates := make([]string, len(columns))
+	for i, column := range columns {
+		updates[i] = fmt.Sprintf("%s = excluded.%s", column, column)
+	}
+	return fmt.Sprintf("%s on conflict (%s) do update set %s", query, strings.Join(key, ", "), strings.Join(updates, ", "))
+}
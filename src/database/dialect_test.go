@@ -0,0 +1,75 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDialectQueries(t *testing.T) {
+	const insert = "insert into followers (user_id, follower_id) select id, ? from users where id = ? and name <> '?'"
+
+	tests := []struct {
+		driver       string
+		rebind       string
+		insertIgnore string
+		upsert       string
+	}{
+		{
+			driver:       "mysql",
+			rebind:       insert,
+			insertIgnore: "insert ignore into followers (user_id, follower_id) select id, ? from users where id = ? and name <> '?'",
+			upsert:       "insert into sessions (id, user_agent) values (?, ?) on duplicate key update user_agent = values(user_agent)",
+		},
+		{
+			driver:       "postgres",
+			rebind:       "insert into followers (user_id, follower_id) select id, $1 from users where id = $2 and name <> '?'",
+			insertIgnore: insert + " on conflict do nothing",
+			upsert:       "insert into sessions (id, user_agent) values (?, ?) on conflict (id) do update set user_agent = excluded.user_agent",
+		},
+		{
+			driver:       "sqlite",
+			rebind:       insert,
+			insertIgnore: insert + " on conflict do nothing",
+			upsert:       "insert into sessions (id, user_agent) values (?, ?) on conflict (id) do update set user_agent = excluded.user_agent",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.driver, func(t *testing.T) {
+			dialect, err := DialectFor(test.driver)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := dialect.Rebind(insert); got != test.rebind {
+				t.Errorf("Rebind: got %q, want %q", got, test.rebind)
+			}
+			if got := dialect.InsertIgnore(insert); got != test.insertIgnore {
+				t.Errorf("InsertIgnore: got %q, want %q", got, test.insertIgnore)
+			}
+			upsert := dialect.Upsert("insert into sessions (id, user_agent) values (?, ?)", []string{"id"}, "user_agent")
+			if upsert != test.upsert {
+				t.Errorf("Upsert: got %q, want %q", upsert, test.upsert)
+			}
+		})
+	}
+}
+
+func TestSplitStatements(t *testing.T) {
+	content := `-- Comments are skipped
+CREATE TABLE a(x varchar(10) default ';');
+
+CREATE TRIGGER a_insert AFTER INSERT ON a BEGIN
+    INSERT INTO b VALUES (new.x);
+    INSERT INTO c VALUES (new.x);
+END;
+DROP TABLE c`
+
+	want := []string{
+		"CREATE TABLE a(x varchar(10) default ';')",
+		"CREATE TRIGGER a_insert AFTER INSERT ON a BEGIN\n    INSERT INTO b VALUES (new.x);\n    INSERT INTO c VALUES (new.x);\nEND",
+		"DROP TABLE c",
+	}
+	if got := splitStatements(content); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
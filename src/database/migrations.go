@@ -0,0 +1,342 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migrations files, one folder per dialect, named as "<version>_<name>.up.sql" and "<version>_<name>.down.sql"
+//
+//go:embed migrations
+var migrationsFiles embed.FS
+
+// migrationFileName matches the migrations files names, capturing their version, name and direction
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration represents a numbered schema change, with the statements which apply and revert it
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus represents a migration and when it was applied (nil if it's pending)
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrations returns the migrations embedded for a dialect, sorted by their versions
+func Migrations(dialect Dialect) ([]Migration, error) {
+	folder := path.Join("migrations", dialect.Driver())
+	files, err := fs.ReadDir(migrationsFiles, folder)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reading the files, grouping both directions of each version
+	byVersion := map[uint64]*Migration{}
+	for _, file := range files {
+		parts := migrationFileName.FindStringSubmatch(file.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("Invalid migration file name! %s", file.Name())
+		}
+		version, _ := strconv.ParseUint(parts[1], 10, 64)
+		content, err := fs.ReadFile(migrationsFiles, path.Join(folder, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = migration
+		} else if migration.Name != parts[2] {
+			return nil, fmt.Errorf("Duplicated migration version! %d", version)
+		}
+		if parts[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	// Sorting the migrations by their versions
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("Migration %d must have both up and down files", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// LatestVersion returns the version of the last migration embedded for a dialect
+func LatestVersion(dialect Dialect) (uint64, error) {
+	migrations, err := Migrations(dialect)
+	if err != nil || len(migrations) == 0 {
+		return 0, err
+	}
+	return migrations[len(migrations)-1].Version, nil
+}
+
+// SchemaVersion returns the version of the last migration applied to the database (zero if there's none)
+func (db *DB) SchemaVersion() (uint64, error) {
+	if err := db.createMigrationsTable(db.pool); err != nil {
+		return 0, err
+	}
+
+	var version sql.NullInt64
+	if err := db.QueryRowContext(context.Background(), "select max(version) from schema_migrations").Scan(&version); err != nil {
+		return 0, err
+	}
+	return uint64(version.Int64), nil
+}
+
+// MigrationsStatus returns all migrations embedded for the database dialect, and when they were applied
+func (db *DB) MigrationsStatus() ([]MigrationStatus, error) {
+	migrations, err := Migrations(db.dialect)
+	if err != nil {
+		return nil, err
+	}
+	if err = db.createMigrationsTable(db.pool); err != nil {
+		return nil, err
+	}
+	applied, err := appliedMigrations(db.pool)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, len(migrations))
+	for i, migration := range migrations {
+		status[i].Migration = migration
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status[i].AppliedAt = &appliedAt
+		}
+	}
+	return status, nil
+}
+
+// MigrateUp applies all pending migrations, returning the ones which were applied
+// Concurrent runners wait for the migrations lock, up to the timeout
+func (db *DB) MigrateUp(lockTimeout time.Duration) ([]Migration, error) {
+	var done []Migration
+	err := db.withMigrationsLock(lockTimeout, func(conn *sql.Conn) error {
+		migrations, err := Migrations(db.dialect)
+		if err != nil {
+			return err
+		}
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		// Applying the migrations which weren't applied yet, in order
+		for _, migration := range migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err = db.runMigration(conn, migration.Up,
+				"insert into schema_migrations (version, name, applied_at) values (?, ?, ?)",
+				migration.Version, migration.Name, time.Now(),
+			); err != nil {
+				return fmt.Errorf("Migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// MigrateDown reverts the last applied migrations (up to the provided number of steps), returning the reverted ones
+// Concurrent runners wait for the migrations lock, up to the timeout
+func (db *DB) MigrateDown(steps int, lockTimeout time.Duration) ([]Migration, error) {
+	var done []Migration
+	err := db.withMigrationsLock(lockTimeout, func(conn *sql.Conn) error {
+		migrations, err := Migrations(db.dialect)
+		if err != nil {
+			return err
+		}
+		applied, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+
+		// Reverting the applied migrations, from the last one
+		for i := len(migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err = db.runMigration(conn, migration.Down,
+				"delete from schema_migrations where version = ?", migration.Version,
+			); err != nil {
+				return fmt.Errorf("Migration %d (%s) revert failed: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// withMigrationsLock runs a function on a dedicated connection, while holding the migrations lock
+func (db *DB) withMigrationsLock(timeout time.Duration, function func(conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := db.pool.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Taking the lock (it's released even if the function fails)
+	if err = db.dialect.LockMigrations(conn, timeout); err != nil {
+		return err
+	}
+	defer db.dialect.UnlockMigrations(conn)
+
+	if err = db.createMigrationsTable(conn); err != nil {
+		return err
+	}
+	return function(conn)
+}
+
+// runMigration executes a migration statements, along with the statement which records it, in a transaction
+// MySQL commits schema changes (e.g. "create table") right away, so a failed migration may be partially applied
+func (db *DB) runMigration(conn *sql.Conn, statements string, record string, args ...interface{}) error {
+	ctx := context.Background()
+	if db.dialect.LockIsTransaction() {
+		return runInSavepoint(conn, func() error {
+			return runStatements(conn, db.dialect, statements, record, args)
+		})
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = runStatements(tx, db.dialect, statements, record, args); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// runInSavepoint runs a function inside a savepoint of the transaction opened on the connection
+// If the function fails, only the changes made after the savepoint are rolled back
+func runInSavepoint(conn *sql.Conn, function func() error) error {
+	ctx := context.Background()
+	if _, err := conn.ExecContext(ctx, "savepoint migration"); err != nil {
+		return err
+	}
+	if err := function(); err != nil {
+		conn.ExecContext(ctx, "rollback to migration")
+		conn.ExecContext(ctx, "release migration")
+		return err
+	}
+	_, err := conn.ExecContext(ctx, "release migration")
+	return err
+}
+
+// runStatements executes a migration statements, followed by the statement which records it
+func runStatements(executor executor, dialect Dialect, statements string, record string, args []interface{}) error {
+	ctx := context.Background()
+	for _, statement := range splitStatements(statements) {
+		if _, err := executor.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	_, err := executor.ExecContext(ctx, dialect.Rebind(record), values(dialect, args)...)
+	return err
+}
+
+// executor is implemented by *sql.DB, *sql.Conn and *sql.Tx
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// createMigrationsTable creates the table which records the applied migrations, if it doesn't exist
+func (db *DB) createMigrationsTable(executor executor) error {
+	_, err := executor.ExecContext(context.Background(), fmt.Sprintf(
+		"create table if not exists schema_migrations (version bigint primary key, name varchar(255) not null, applied_at %s not null)",
+		db.dialect.TimestampType(),
+	))
+	return err
+}
+
+// appliedMigrations returns when each applied migration was applied, by their versions
+func appliedMigrations(executor executor) (map[uint64]time.Time, error) {
+	rows, err := executor.QueryContext(context.Background(), "select version, applied_at from schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[uint64]time.Time{}
+	for rows.Next() {
+		var version uint64
+		var appliedAt time.Time
+		if err = rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// splitStatements splits a migration file into its statements (separated by semicolons), ignoring comment lines
+// Triggers are kept whole, since their body statements are separated by semicolons too
+func splitStatements(content string) []string {
+	var statements []string
+	var current strings.Builder
+	quoted := false
+	for _, line := range strings.Split(content, "\n") {
+		if !quoted && strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		for _, char := range line {
+			if char == '\'' {
+				quoted = !quoted
+			}
+			if char == ';' && !quoted && !isTriggerBody(current.String()) {
+				statements = appendStatement(statements, current.String())
+				current.Reset()
+				continue
+			}
+			current.WriteRune(char)
+		}
+		current.WriteRune('\n')
+	}
+	return appendStatement(statements, current.String())
+}
+
+// isTriggerBody checks if a (partial) statement creates a trigger whose body wasn't ended yet
+func isTriggerBody(statement string) bool {
+	statement = strings.ToUpper(strings.TrimSpace(statement))
+	return strings.HasPrefix(statement, "CREATE TRIGGER") && !strings.HasSuffix(statement, "END")
+}
+
+// appendStatement appends a statement to the list, unless it's empty
+func appendStatement(statements []string, statement string) []string {
+	if statement = strings.TrimSpace(statement); statement != "" {
+		statements = append(statements, statement)
+	}
+	return statements
+}
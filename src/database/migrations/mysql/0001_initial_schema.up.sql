@@ -1,11 +1,4 @@
-CREATE DATABASE IF NOT EXISTS devbook;
-USE devbook;
-
-DROP TABLE IF EXISTS posts;
-DROP TABLE IF EXISTS followers;
-DROP TABLE IF EXISTS users;
-
-CREATE TABLE users(
+CREATE TABLE IF NOT EXISTS users(
     id int auto_increment primary key,
     name varchar(50) not null,
     username varchar(50) not null unique,
@@ -14,7 +7,7 @@ CREATE TABLE users(
     createdAt timestamp default current_timestamp()
 ) ENGINE=INNODB;
 
-CREATE TABLE followers(
+CREATE TABLE IF NOT EXISTS followers(
     user_id int not null,
     FOREIGN KEY (user_id)
     REFERENCES users(id)
@@ -28,7 +21,7 @@ CREATE TABLE followers(
     PRIMARY KEY(user_id, follower_id)
 ) ENGINE=INNODB;
 
-CREATE TABLE posts(
+CREATE TABLE IF NOT EXISTS posts(
     id int auto_increment primary key,
     title varchar(50) not null,
     content varchar(300) not null,
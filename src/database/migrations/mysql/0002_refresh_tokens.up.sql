@@ -0,0 +1,17 @@
+-- Refresh tokens of the same family are rotated from one login, so reusing an old one revokes the whole family
+CREATE TABLE IF NOT EXISTS refresh_tokens(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    family_id varchar(50) not null,
+    token_hash char(64) not null unique,
+    expires_at datetime not null,
+    revoked_at datetime null default null,
+    createdAt timestamp default current_timestamp(),
+
+    INDEX (family_id)
+) ENGINE=INNODB;
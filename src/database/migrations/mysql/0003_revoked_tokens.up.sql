@@ -0,0 +1,16 @@
+-- Access tokens revoked before they expire (e.g. on logout), and users whose tokens were all revoked
+CREATE TABLE IF NOT EXISTS revoked_tokens(
+    token_id varchar(50) primary key,
+    expires_at datetime not null,
+
+    INDEX (expires_at)
+) ENGINE=INNODB;
+
+CREATE TABLE IF NOT EXISTS revoked_users(
+    user_id int primary key,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    revoked_before datetime not null
+) ENGINE=INNODB;
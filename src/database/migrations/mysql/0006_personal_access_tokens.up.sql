@@ -0,0 +1,17 @@
+-- Long-lived tokens created by the users for scripts and integrations, limited to some scopes
+CREATE TABLE IF NOT EXISTS personal_access_tokens(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    name varchar(50) not null,
+    token_hash char(64) not null unique,
+    scopes varchar(255) not null,
+    last_used_at datetime null default null,
+    expires_at datetime null default null,
+    revoked_at datetime null default null,
+    createdAt timestamp default current_timestamp()
+) ENGINE=INNODB;
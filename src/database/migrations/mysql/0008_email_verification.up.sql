@@ -0,0 +1,5 @@
+-- When each user email was verified (null while it isn't)
+ALTER TABLE users ADD COLUMN email_verified_at datetime null default null;
+
+-- The users created before the emails were verified keep being allowed to post
+UPDATE users SET email_verified_at = createdAt;
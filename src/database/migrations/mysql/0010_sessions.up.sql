@@ -0,0 +1,17 @@
+-- Login sessions (each one is a refresh tokens family), which the users can list and revoke
+CREATE TABLE IF NOT EXISTS sessions(
+    id varchar(64) primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    user_agent varchar(255) not null default '',
+    ip_address varchar(45) not null default '',
+    last_used_at datetime not null,
+    revoked_at datetime null default null,
+    createdAt timestamp default current_timestamp(),
+
+    INDEX (user_id, last_used_at)
+) ENGINE=INNODB;
@@ -0,0 +1,2 @@
+ALTER TABLE posts DROP INDEX posts_deleted_at, DROP COLUMN deleted_at;
+ALTER TABLE users DROP INDEX users_deleted_at, DROP COLUMN deleted_at;
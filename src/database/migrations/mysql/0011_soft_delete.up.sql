@@ -0,0 +1,3 @@
+-- Deleted users and posts are kept (hidden) until they're purged, so they can be restored
+ALTER TABLE users ADD COLUMN deleted_at datetime null default null, ADD INDEX users_deleted_at (deleted_at);
+ALTER TABLE posts ADD COLUMN deleted_at datetime null default null, ADD INDEX posts_deleted_at (deleted_at);
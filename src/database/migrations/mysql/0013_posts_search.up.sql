@@ -0,0 +1,2 @@
+-- Full-text index used to search the posts by keywords
+ALTER TABLE posts ADD FULLTEXT INDEX posts_search (title, content);
@@ -0,0 +1,3 @@
+-- Recovery codes are hashed with the password hasher, and TOTP secrets are encrypted, so both need longer columns
+ALTER TABLE recovery_codes MODIFY code_hash varchar(255) not null;
+ALTER TABLE users MODIFY totp_secret varchar(255) null default null;
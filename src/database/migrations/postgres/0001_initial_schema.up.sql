@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS users(
+    id serial primary key,
+    name varchar(50) not null,
+    username varchar(50) not null unique,
+    email varchar(50) not null unique,
+    pass varchar(100) not null,
+    createdAt timestamptz default current_timestamp
+);
+
+CREATE TABLE IF NOT EXISTS followers(
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    follower_id int not null,
+    FOREIGN KEY (follower_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    PRIMARY KEY(user_id, follower_id)
+);
+
+CREATE TABLE IF NOT EXISTS posts(
+    id serial primary key,
+    title varchar(50) not null,
+    content varchar(300) not null,
+
+    author_id int not null,
+    FOREIGN KEY (author_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    likes int default 0,
+    createdAt timestamptz default current_timestamp
+);
@@ -0,0 +1,17 @@
+-- Refresh tokens of the same family are rotated from one login, so reusing an old one revokes the whole family
+CREATE TABLE IF NOT EXISTS refresh_tokens(
+    id serial primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    family_id varchar(50) not null,
+    token_hash char(64) not null unique,
+    expires_at timestamptz not null,
+    revoked_at timestamptz null default null,
+    createdAt timestamptz default current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id ON refresh_tokens (family_id);
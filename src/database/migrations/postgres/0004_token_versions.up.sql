@@ -0,0 +1,2 @@
+-- Incremented when all tokens issued to an user must be rejected (e.g. when the password changes)
+ALTER TABLE users ADD COLUMN token_version int not null default 0;
@@ -0,0 +1,6 @@
+-- Deleted users and posts are kept (hidden) until they're purged, so they can be restored
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at timestamptz null default null;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS deleted_at timestamptz null default null;
+
+CREATE INDEX IF NOT EXISTS users_deleted_at ON users (deleted_at);
+CREATE INDEX IF NOT EXISTS posts_deleted_at ON posts (deleted_at);
@@ -0,0 +1,3 @@
+-- The counters keep the likes recorded on the table, which become legacy likes again
+DROP TABLE IF EXISTS post_likes;
+ALTER TABLE posts DROP COLUMN IF EXISTS legacy_likes;
@@ -0,0 +1,2 @@
+-- Full-text index used to search the posts by keywords (the expression must match the one used by the searches)
+CREATE INDEX IF NOT EXISTS posts_search ON posts USING gin (to_tsvector('simple', title || ' ' || content));
@@ -0,0 +1,6 @@
+-- Recovery codes hashed like passwords and encrypted TOTP secrets can't be read by the previous version (two-factor
+-- authentication must be enrolled again by the users whose secrets are encrypted)
+DELETE FROM recovery_codes WHERE code_hash LIKE '$%';
+UPDATE users SET totp_secret = null, totp_enabled = false, totp_last_step = 0 WHERE totp_secret LIKE 'enc:%';
+ALTER TABLE recovery_codes ALTER COLUMN code_hash TYPE char(64);
+ALTER TABLE users ALTER COLUMN totp_secret TYPE varchar(64);
@@ -0,0 +1,3 @@
+-- Recovery codes are hashed with the password hasher, and TOTP secrets are encrypted, so both need longer columns
+ALTER TABLE recovery_codes ALTER COLUMN code_hash TYPE varchar(255);
+ALTER TABLE users ALTER COLUMN totp_secret TYPE varchar(255);
@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS users(
+    id integer primary key autoincrement,
+    name varchar(50) not null,
+    username varchar(50) not null unique,
+    email varchar(50) not null unique,
+    pass varchar(100) not null,
+    createdAt timestamp default current_timestamp
+);
+
+CREATE TABLE IF NOT EXISTS followers(
+    user_id int not null
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    follower_id int not null
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    PRIMARY KEY(user_id, follower_id)
+);
+
+CREATE TABLE IF NOT EXISTS posts(
+    id integer primary key autoincrement,
+    title varchar(50) not null,
+    content varchar(300) not null,
+
+    author_id int not null
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    likes int default 0,
+    createdAt timestamp default current_timestamp
+);
@@ -0,0 +1,15 @@
+-- Access tokens revoked before they expire (e.g. on logout), and users whose tokens were all revoked
+CREATE TABLE IF NOT EXISTS revoked_tokens(
+    token_id varchar(50) primary key,
+    expires_at datetime not null
+);
+
+CREATE TABLE IF NOT EXISTS revoked_users(
+    user_id int primary key
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    revoked_before datetime not null
+);
+
+CREATE INDEX IF NOT EXISTS revoked_tokens_expires_at ON revoked_tokens (expires_at);
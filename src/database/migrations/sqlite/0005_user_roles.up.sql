@@ -0,0 +1,2 @@
+-- Role of each user, which defines the routes the user is allowed to access
+ALTER TABLE users ADD COLUMN role varchar(20) not null default 'user';
@@ -0,0 +1,19 @@
+-- Two-factor authentication (TOTP) settings of each user, along with the time step of the last accepted code
+ALTER TABLE users ADD COLUMN totp_secret varchar(64) null default null;
+ALTER TABLE users ADD COLUMN totp_enabled boolean not null default false;
+ALTER TABLE users ADD COLUMN totp_last_step bigint not null default 0;
+
+-- One-time codes which may replace the TOTP codes (e.g. when the device is lost)
+CREATE TABLE IF NOT EXISTS recovery_codes(
+    id integer primary key autoincrement,
+
+    user_id int not null
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    code_hash char(64) not null,
+    used_at datetime null default null,
+    createdAt timestamp default current_timestamp,
+
+    UNIQUE (user_id, code_hash)
+);
@@ -0,0 +1,13 @@
+-- Single-use tokens sent by email to reset the users passwords
+CREATE TABLE IF NOT EXISTS password_reset_tokens(
+    id integer primary key autoincrement,
+
+    user_id int not null
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    token_hash char(64) not null unique,
+    expires_at datetime not null,
+    used_at datetime null default null,
+    createdAt timestamp default current_timestamp
+);
@@ -0,0 +1,16 @@
+-- Login sessions (each one is a refresh tokens family), which the users can list and revoke
+CREATE TABLE IF NOT EXISTS sessions(
+    id varchar(64) primary key,
+
+    user_id int not null
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    user_agent varchar(255) not null default '',
+    ip_address varchar(45) not null default '',
+    last_used_at datetime not null,
+    revoked_at datetime null default null,
+    createdAt timestamp default current_timestamp
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_last_used_at ON sessions (user_id, last_used_at);
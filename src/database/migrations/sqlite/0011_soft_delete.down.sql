@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS posts_deleted_at;
+DROP INDEX IF EXISTS users_deleted_at;
+
+ALTER TABLE posts DROP COLUMN deleted_at;
+ALTER TABLE users DROP COLUMN deleted_at;
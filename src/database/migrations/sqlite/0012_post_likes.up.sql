@@ -0,0 +1,21 @@
+-- Each user likes a post at most once, and the posts likes counters are kept consistent with this table
+CREATE TABLE IF NOT EXISTS post_likes(
+    post_id int not null
+    REFERENCES posts(id)
+    ON DELETE CASCADE,
+
+    user_id int not null
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    createdAt timestamp default current_timestamp,
+
+    PRIMARY KEY(post_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS post_likes_user_id ON post_likes (user_id);
+
+-- The likes given before can't be attributed to their users, so they're kept apart: the counters are the legacy
+-- likes plus the likes recorded on the table
+ALTER TABLE posts ADD COLUMN legacy_likes int not null default 0;
+UPDATE posts SET legacy_likes = likes;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS posts_search_update;
+DROP TRIGGER IF EXISTS posts_search_delete;
+DROP TRIGGER IF EXISTS posts_search_insert;
+DROP TABLE IF EXISTS posts_search;
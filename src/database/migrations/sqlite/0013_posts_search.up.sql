@@ -0,0 +1,18 @@
+-- Full-text index used to search the posts by keywords, which is kept up to date by the triggers
+CREATE VIRTUAL TABLE IF NOT EXISTS posts_search USING fts5(title, content, content='posts', content_rowid='id');
+
+CREATE TRIGGER IF NOT EXISTS posts_search_insert AFTER INSERT ON posts BEGIN
+    INSERT INTO posts_search (rowid, title, content) VALUES (new.id, new.title, new.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS posts_search_delete AFTER DELETE ON posts BEGIN
+    INSERT INTO posts_search (posts_search, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+END;
+
+CREATE TRIGGER IF NOT EXISTS posts_search_update AFTER UPDATE OF title, content ON posts BEGIN
+    INSERT INTO posts_search (posts_search, rowid, title, content) VALUES ('delete', old.id, old.title, old.content);
+    INSERT INTO posts_search (rowid, title, content) VALUES (new.id, new.title, new.content);
+END;
+
+-- Indexing the existing posts
+INSERT INTO posts_search (posts_search) VALUES ('rebuild');
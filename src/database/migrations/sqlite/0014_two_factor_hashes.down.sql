@@ -0,0 +1,4 @@
+-- Recovery codes hashed like passwords and encrypted TOTP secrets can't be read by the previous version (two-factor
+-- authentication must be enrolled again by the users whose secrets are encrypted)
+DELETE FROM recovery_codes WHERE code_hash LIKE '$%';
+UPDATE users SET totp_secret = null, totp_enabled = false, totp_last_step = 0 WHERE totp_secret LIKE 'enc:%';
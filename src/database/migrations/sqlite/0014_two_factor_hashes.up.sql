@@ -0,0 +1,2 @@
+-- Recovery codes are hashed with the password hasher, and TOTP secrets are encrypted, so both need longer columns
+-- SQLite doesn't enforce the columns lengths, so the existing columns already fit them
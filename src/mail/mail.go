@@ -0,0 +1,99 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	netmail "net/mail"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message represents an email message
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer represents a service which sends email messages
+type Mailer interface {
+	Send(message Message) error
+}
+
+// SMTPMailer sends email messages through a SMTP server
+type SMTPMailer struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPMailer instantiates/initializes a SMTP mailer
+// If no username is provided, messages are sent without authentication
+func NewSMTPMailer(host string, port int, username, password, from string) *SMTPMailer {
+	return &SMTPMailer{host, port, username, password, from}
+}
+
+// Send sends the message through the SMTP server
+func (mailer *SMTPMailer) Send(message Message) error {
+	// Setting the authentication, if credentials were provided
+	var auth smtp.Auth
+	if mailer.username != "" {
+		auth = smtp.PlainAuth("", mailer.username, mailer.password, mailer.host)
+	}
+
+	// The envelope sender must be a bare address (the sender may be provided with a display name)
+	sender, err := netmail.ParseAddress(mailer.from)
+	if err != nil {
+		return err
+	}
+
+	// Sending the message
+	address := fmt.Sprintf("%s:%d", mailer.host, mailer.port)
+	return smtp.SendMail(address, auth, sender.Address, []string{message.To}, format(mailer.from, message))
+}
+
+// LogMailer writes email messages to a writer (e.g. a file or the terminal) instead of sending them
+// It's useful for local development, where no SMTP server is available
+type LogMailer struct {
+	mutex  sync.Mutex
+	writer io.Writer
+	from   string
+}
+
+// NewLogMailer instantiates/initializes a log mailer
+func NewLogMailer(writer io.Writer, from string) *LogMailer {
+	return &LogMailer{writer: writer, from: from}
+}
+
+// Send writes the message to the writer
+func (mailer *LogMailer) Send(message Message) error {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	// Messages are separated by a line with the sending time
+	if _, err := fmt.Fprintf(mailer.writer, "\n----- %s -----\n", time.Now().Format(time.RFC3339)); err != nil {
+		return err
+	}
+	_, err := mailer.writer.Write(format(mailer.from, message))
+	return err
+}
+
+// headerReplacer removes line breaks from header values, so no other headers can be injected
+var headerReplacer = strings.NewReplacer("\r", "", "\n", "")
+
+// format formats the message with its headers (RFC 5322)
+func format(from string, message Message) []byte {
+	var builder strings.Builder
+	builder.WriteString("From: " + headerReplacer.Replace(from) + "\r\n")
+	builder.WriteString("To: " + headerReplacer.Replace(message.To) + "\r\n")
+	builder.WriteString("Subject: " + headerReplacer.Replace(message.Subject) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(message.Body + "\r\n")
+	return []byte(builder.String())
+}
@@ -2,11 +2,29 @@ package middlewares
 
 import (
 	"api/src/authentication"
+	"api/src/database"
+	"api/src/repositories"
 	"api/src/responses"
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
+// Middlewares holds the dependencies of the middlewares which check data on the database
+type Middlewares struct {
+	// Database connection pool, shared by all requests
+	db *database.DB
+	// Users data (stored on the database or on memory)
+	users repositories.UserStore
+}
+
+// New instantiates/initializes the middlewares with their dependencies
+func New(db *database.DB, users repositories.UserStore) *Middlewares {
+	return &Middlewares{db, users}
+}
+
 // Logger writes request data on terminal
 func Logger(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -17,14 +35,210 @@ func Logger(next http.HandlerFunc) http.HandlerFunc {
 }
 
 // Authenticate checks if user making the request is authenticated
-func Authenticate(next http.HandlerFunc) http.HandlerFunc {
+// The token claims are stored on the request context, so handlers don't need to parse the token again
+// Personal access tokens are accepted as well, but they're limited to their scopes
+func (middlewares *Middlewares) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		// Checking if a personal access token was provided, instead of a JWT
+		if token, ok := authentication.ExtractPersonalAccessToken(r); ok {
+			claims, err := middlewares.validatePersonalAccessToken(r.Context(), token)
+			if err != nil {
+				responses.Error(w, http.StatusInternalServerError, err)
+				return
+			}
+			if claims == nil {
+				responses.Error(w, http.StatusUnauthorized, errors.New("Invalid token"))
+				return
+			}
+			// Goes to the next middleware/request handler function, with the claims on the request context
+			next(w, r.WithContext(authentication.WithClaims(r.Context(), claims)))
+			return
+		}
+
 		// Checking if token is valid
-		if err := authentication.ValidateToken(r); err != nil {
+		claims, err := authentication.ParseToken(r)
+		if err != nil {
 			responses.Error(w, http.StatusUnauthorized, err)
 			return
 		}
+		// Checking if token was revoked (e.g. after a logout)
+		revoked, err := authentication.IsRevoked(r.Context(), claims)
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+		if revoked {
+			responses.Error(w, http.StatusUnauthorized, errors.New("Token has been revoked"))
+			return
+		}
+		// Checking if token was issued before the user credentials changed (or the user was deleted)
+		current, err := middlewares.isTokenVersionCurrent(r.Context(), claims)
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+		if !current {
+			responses.Error(w, http.StatusUnauthorized, errors.New("Token is no longer valid, please log in again"))
+			return
+		}
+		// Checking if the token session is still alive (e.g. it wasn't ended from another device)
+		alive, err := middlewares.isSessionAlive(r.Context(), claims)
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+		if !alive {
+			responses.Error(w, http.StatusUnauthorized, errors.New("Session has ended, please log in again"))
+			return
+		}
+		// Goes to the next middleware/request handler function, with the claims on the request context
+		next(w, r.WithContext(authentication.WithClaims(r.Context(), claims)))
+	}
+}
+
+// Authorize checks if the authenticated user has any of the required roles and all the required permissions
+// It must be used after the Authenticate middleware, since it reads the token claims from the request context
+func Authorize(roles, permissions []string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Getting the claims provided on the token
+		claims, err := authentication.ExtractClaims(r)
+		if err != nil {
+			responses.Error(w, http.StatusUnauthorized, err)
+			return
+		}
+		// Checking the user roles
+		if len(roles) > 0 && !claims.HasAnyRole(roles...) {
+			responses.Error(w, http.StatusForbidden, errors.New("You don't have the required role to access this resource"))
+			return
+		}
+		// Checking the user permissions
+		for _, permission := range permissions {
+			if !claims.HasPermission(permission) {
+				responses.Error(w, http.StatusForbidden, fmt.Errorf("You don't have the required permission to access this resource: %s", permission))
+				return
+			}
+		}
 		// Goes to the next middleware/request handler function
 		next(w, r)
 	}
 }
+
+// RequireScopes checks if the token used on the request allows all the required scopes
+// Routes without scopes can't be accessed with personal access tokens at all
+func RequireScopes(scopes []string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Getting the claims provided on the token
+		claims, err := authentication.ExtractClaims(r)
+		if err != nil {
+			responses.Error(w, http.StatusUnauthorized, err)
+			return
+		}
+		// Checking the token scopes
+		if claims.IsPersonalAccessToken() && (len(scopes) == 0 || !claims.HasScopes(scopes...)) {
+			responses.Error(w, http.StatusForbidden, errors.New("Token doesn't have the required scopes to access this resource"))
+			return
+		}
+		// Goes to the next middleware/request handler function
+		next(w, r)
+	}
+}
+
+// RequireVerifiedEmail checks if the authenticated user has confirmed the email address
+// It must be used after the Authenticate middleware, since it reads the token claims from the request context
+func (middlewares *Middlewares) RequireVerifiedEmail(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Getting the user ID provided on the token
+		userID, err := authentication.ExtractUserID(r)
+		if err != nil {
+			responses.Error(w, http.StatusUnauthorized, err)
+			return
+		}
+		// Checking the user email on the database (it may have been verified after the token was issued)
+		verified, err := middlewares.isEmailVerified(r.Context(), userID)
+		if err != nil {
+			responses.Error(w, http.StatusInternalServerError, err)
+			return
+		}
+		if !verified {
+			responses.Error(w, http.StatusForbidden, errors.New("You must verify your email before accessing this resource"))
+			return
+		}
+		// Goes to the next middleware/request handler function
+		next(w, r)
+	}
+}
+
+// validatePersonalAccessToken checks if a personal access token is active, returning its claims
+// If the token is not valid, no claims are returned
+func (middlewares *Middlewares) validatePersonalAccessToken(ctx context.Context, token string) (*authentication.Claims, error) {
+	// Searching the token on the repository (only its hash is stored)
+	repository := repositories.NewPersonalAccessTokensRepository(middlewares.db)
+	savedToken, err := repository.SearchByHash(ctx, authentication.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	// Checking if the token exists and is still active
+	if savedToken.ID == 0 || savedToken.RevokedAt != nil ||
+		(savedToken.ExpiresAt != nil && time.Now().After(*savedToken.ExpiresAt)) {
+		return nil, nil
+	}
+
+	// Registering the token usage
+	if err = repository.UpdateLastUsed(ctx, savedToken.ID); err != nil {
+		return nil, err
+	}
+
+	// Personal access tokens carry no roles, they're limited to their scopes
+	return &authentication.Claims{
+		UserID:                savedToken.UserID,
+		PersonalAccessTokenID: savedToken.ID,
+		Scopes:                savedToken.Scopes,
+	}, nil
+}
+
+// isTokenVersionCurrent checks if the token version matches the user's current one
+func (middlewares *Middlewares) isTokenVersionCurrent(ctx context.Context, claims *authentication.Claims) (bool, error) {
+	// Searching the user current token version on the repository
+	user, err := middlewares.users.SearchTokenData(ctx, claims.UserID)
+	if err != nil {
+		return false, err
+	}
+
+	// Deleted users have no valid tokens
+	return user.ID != 0 && user.TokenVersion == claims.TokenVersion, nil
+}
+
+// sessionTouchInterval defines how often the session last used time is updated
+const sessionTouchInterval = time.Minute
+
+// isSessionAlive checks if the token session exists and wasn't ended, updating its last used time
+func (middlewares *Middlewares) isSessionAlive(ctx context.Context, claims *authentication.Claims) (bool, error) {
+	if claims.SessionID == "" {
+		return false, nil
+	}
+
+	// Searching the session on the repository
+	repository := repositories.NewSessionsRepository(middlewares.db)
+	session, err := repository.SearchByID(ctx, claims.SessionID)
+	if err != nil {
+		return false, err
+	}
+	if session.ID == "" || session.UserID != claims.UserID || session.RevokedAt != nil {
+		return false, nil
+	}
+
+	// Registering the session usage
+	return true, repository.Touch(ctx, session.ID, time.Now().Add(-sessionTouchInterval))
+}
+
+// isEmailVerified checks if the user has confirmed the current email address
+func (middlewares *Middlewares) isEmailVerified(ctx context.Context, userID uint64) (bool, error) {
+	// Searching the user on the repository
+	user, err := middlewares.users.SearchByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return user.EmailVerifiedAt != nil, nil
+}
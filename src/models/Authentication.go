@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// AuthenticationData represents the tokens returned to an authenticated user
+type AuthenticationData struct {
+	ID    uint64 `json:"id"`
+	Token string `json:"token"`
+	// How the token must be sent (on the Authorization header)
+	TokenType string `json:"tokenType"`
+	// Access token lifetime, in seconds, and when it expires
+	ExpiresIn    int64     `json:"expiresIn"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	RefreshToken string    `json:"refreshToken"`
+	// Basic profile of the authenticated user (only returned on login)
+	User *User `json:"user,omitempty"`
+}
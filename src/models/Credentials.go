@@ -0,0 +1,43 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// Credentials represents the login request format
+// Users can log in with their username or email, provided on the login field
+// The email and pass fields are still accepted, for clients using the previous format
+type Credentials struct {
+	Login    string `json:"login,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Password string `json:"password,omitempty"`
+	Pass     string `json:"pass,omitempty"`
+}
+
+// Prepare validates the credentials, moving the previous format fields to the current ones
+func (credentials *Credentials) Prepare() error {
+	if credentials.Login == "" {
+		credentials.Login = credentials.Email
+	}
+	if credentials.Password == "" {
+		credentials.Password = credentials.Pass
+	}
+	credentials.Email, credentials.Pass = "", ""
+
+	// Removing trailing/leading spaces
+	credentials.Login = strings.TrimSpace(credentials.Login)
+
+	if credentials.Login == "" {
+		return errors.New("Login is a required field, cannot be left blank")
+	}
+	if credentials.Password == "" {
+		return errors.New("Password is a required field, cannot be left blank")
+	}
+	return nil
+}
+
+// IsEmail checks if the login is an email (otherwise it's an username)
+func (credentials *Credentials) IsEmail() bool {
+	return strings.Contains(credentials.Login, "@")
+}
@@ -0,0 +1,6 @@
+package models
+
+// EmailVerification represents the token sent by email to confirm the user's email address
+type EmailVerification struct {
+	Token string `json:"token,omitempty"`
+}
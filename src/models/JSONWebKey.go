@@ -0,0 +1,20 @@
+package models
+
+// JSONWebKey represents a public key used to verify the tokens signature (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA keys parameters
+	Modulus  string `json:"n,omitempty"`
+	Exponent string `json:"e,omitempty"`
+	// Ed25519 keys parameters
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet represents the set of public keys currently accepted by the API
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
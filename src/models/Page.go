@@ -0,0 +1,23 @@
+package models
+
+// PageRequest selects a page of a list by the position of its items (keyset pagination), so pages stay stable
+// while new items are added. At most one of After and Before is set, with the ID of the item where the page starts
+// (next pages) or ends (previous pages), excluding the item itself
+type PageRequest struct {
+	Limit  int
+	After  uint64
+	Before uint64
+}
+
+// Backward tells whether the page is the one before an item (a previous page)
+func (request PageRequest) Backward() bool {
+	return request.Before != 0
+}
+
+// Page represents a page of a list, along with the cursors which select the next and previous pages
+// The cursors are empty when there are no pages in that direction
+type Page[T any] struct {
+	Items          []T    `json:"items"`
+	NextCursor     string `json:"nextCursor,omitempty"`
+	PreviousCursor string `json:"previousCursor,omitempty"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// PasswordResetToken represents a single-use token, sent by email, used to reset an user's password
+type PasswordResetToken struct {
+	ID        uint64
+	UserID    uint64
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// PasswordForgot represents the request format to receive a password reset link
+type PasswordForgot struct {
+	Email string `json:"email"`
+}
+
+// PasswordReset represents the request format to reset a password with the token sent by email
+type PasswordReset struct {
+	Token string `json:"token"`
+	New   string `json:"new"`
+}
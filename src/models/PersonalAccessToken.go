@@ -0,0 +1,75 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// PersonalAccessToken represents a long-lived token, with limited scopes, used by scripts and bots
+type PersonalAccessToken struct {
+	ID     uint64   `json:"id,omitempty"`
+	UserID uint64   `json:"userId,omitempty"`
+	Name   string   `json:"name,omitempty"`
+	Scopes []string `json:"scopes"`
+	// The token itself is only returned when it's created, just its hash is stored
+	Token      string     `json:"token,omitempty"`
+	TokenHash  string     `json:"-"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt  *time.Time `json:"-"`
+	CreatedAt  time.Time  `json:"createdAt,omitempty"`
+}
+
+// Prepare method calls the other methods to adequate token instance for insertion on database
+func (token *PersonalAccessToken) Prepare() error {
+	token.format()
+	if err := token.validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validate checks if token instance is valid
+func (token *PersonalAccessToken) validate() error {
+	// If an error is identified
+	if token.Name == "" {
+		return errors.New("Name is a required field, cannot be left blank")
+	}
+	if len(token.Scopes) == 0 {
+		return errors.New("Scopes is a required field, at least one scope must be provided")
+	}
+	if token.ExpiresAt != nil && token.ExpiresAt.Before(time.Now()) {
+		return errors.New("ExpiresAt must be a future date")
+	}
+
+	// If no error is identified
+	return nil
+}
+
+// format updates token fields, in order to meet the desired format
+func (token *PersonalAccessToken) format() {
+	// Removing trailing/leading spaces
+	token.Name = strings.TrimSpace(token.Name)
+
+	// Removing blank and duplicated scopes
+	var scopes []string
+	for _, scope := range token.Scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || contains(scopes, scope) {
+			continue
+		}
+		scopes = append(scopes, scope)
+	}
+	token.Scopes = scopes
+}
+
+// contains checks if a string is present on a slice
+func contains(items []string, item string) bool {
+	for _, existingItem := range items {
+		if existingItem == item {
+			return true
+		}
+	}
+	return false
+}
@@ -14,7 +14,10 @@ type Post struct {
 	AuthorID       uint64    `json:"authorId,omitempty"`
 	AuthorUsername string    `json:"authorUsername,omitempty"`
 	Likes          uint64    `json:"likes"`
+	LikedByMe      bool      `json:"likedByMe"`
 	CreatedAt      time.Time `json:"createdAt,omitempty"`
+	// Set when the post is deleted (it's kept until purged, so it can be restored)
+	DeletedAt *time.Time `json:"-"`
 }
 
 // Prepare method calls the other methods to adequate post instance for insertion on database
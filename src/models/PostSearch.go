@@ -0,0 +1,100 @@
+package models
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// maxSearchTerms limits the terms of a search, since each one makes the search slower
+const maxSearchTerms = 10
+
+// PostSearch represents a full-text search over the posts, along with its filters
+type PostSearch struct {
+	// Query as typed by the user: words, "quoted phrases" and prefixes (words ending with *)
+	Query string
+	// Terms read from the query, which must all be found on the matching posts
+	Terms []SearchTerm
+	// Optional filters: the author, and the creation time range (From is inclusive and To is exclusive)
+	AuthorID uint64
+	From     time.Time
+	To       time.Time
+	// Maximum number of posts returned (the most relevant ones)
+	Limit int
+}
+
+// SearchTerm is a term of a search: either a word, a phrase (several words in sequence) or a prefix
+// (a single word which the matching words start with). Words are lowercase, with only letters and digits
+type SearchTerm struct {
+	Words  []string
+	Prefix bool
+}
+
+// PostSearchResult represents a post found by a search, with a snippet of its content
+// The snippet is HTML (escaped), with the matching words inside <mark> tags
+type PostSearchResult struct {
+	Post
+	Snippet string `json:"snippet"`
+}
+
+// Prepare method reads the search terms from the query, and checks if the search is valid
+func (search *PostSearch) Prepare() error {
+	search.Terms = parseSearchTerms(search.Query)
+	return search.validate()
+}
+
+// validate checks if search instance is valid
+func (search *PostSearch) validate() error {
+	// If an error is identified
+	if len(search.Terms) == 0 {
+		return errors.New("The search query must have at least one word")
+	}
+	if len(search.Terms) > maxSearchTerms {
+		return errors.New("The search query can't have more than " + strconv.Itoa(maxSearchTerms) + " terms")
+	}
+	if !search.From.IsZero() && !search.To.IsZero() && !search.From.Before(search.To) {
+		return errors.New("The search start date must be before the end date")
+	}
+
+	// If no error is identified
+	return nil
+}
+
+// parseSearchTerms reads the terms from a search query
+// Other characters than letters and digits separate the words, so they never reach the search engine
+func parseSearchTerms(query string) []SearchTerm {
+	var terms []SearchTerm
+	for query = strings.TrimSpace(query); query != ""; query = strings.TrimSpace(query) {
+		// Reading a quoted phrase (until the closing quote, or the end of the query)
+		if query[0] == '"' {
+			phrase, rest, _ := strings.Cut(query[1:], `"`)
+			if words := SearchWords(phrase); len(words) > 0 {
+				terms = append(terms, SearchTerm{Words: words})
+			}
+			query = rest
+			continue
+		}
+
+		// Reading a word (words joined by other characters, like "e-mail", are read as a phrase)
+		end := strings.IndexFunc(query, unicode.IsSpace)
+		if end < 0 {
+			end = len(query)
+		}
+		token, rest := query[:end], query[end:]
+		words := SearchWords(token)
+		if len(words) > 0 {
+			terms = append(terms, SearchTerm{Words: words, Prefix: len(words) == 1 && strings.HasSuffix(token, "*")})
+		}
+		query = rest
+	}
+	return terms
+}
+
+// SearchWords splits a text into the lowercase words which are searched (sequences of letters and digits)
+func SearchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(char rune) bool {
+		return !unicode.IsLetter(char) && !unicode.IsDigit(char)
+	})
+}
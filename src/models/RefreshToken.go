@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// RefreshToken represents a long-lived token, stored on the server, used to get new access tokens
+type RefreshToken struct {
+	ID        uint64
+	UserID    uint64
+	FamilyID  string
+	TokenHash string
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+	CreatedAt time.Time
+}
+
+// RefreshRequest represents the request format to exchange a refresh token
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
@@ -0,0 +1,6 @@
+package models
+
+// Role represents an user's role change request format
+type Role struct {
+	Role string `json:"role"`
+}
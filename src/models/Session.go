@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Session represents a logged in device, whose tokens belong to the same refresh tokens family
+type Session struct {
+	ID         string     `json:"id"`
+	UserID     uint64     `json:"-"`
+	UserAgent  string     `json:"userAgent"`
+	IPAddress  string     `json:"ipAddress"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt time.Time  `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"-"`
+	// Whether it's the session used on the request
+	Current bool `json:"current"`
+}
@@ -0,0 +1,45 @@
+package models
+
+// TwoFactor represents an user's two-factor authentication (TOTP) settings
+type TwoFactor struct {
+	Secret  string
+	Enabled bool
+	// Time step of the last accepted code, so codes can't be used twice
+	LastStep int64
+}
+
+// TwoFactorEnrollment represents the data returned to set up an authenticator app
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// TwoFactorCode represents a TOTP code sent to enable two-factor authentication
+type TwoFactorCode struct {
+	Code string `json:"code"`
+}
+
+// RecoveryCodes represents the one-time codes which may replace TOTP codes (e.g. when the device is lost)
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// RecoveryCode represents a stored recovery code, which is identified by its hash
+type RecoveryCode struct {
+	ID       uint64
+	CodeHash string
+}
+
+// TwoFactorChallenge represents the response of a login which requires a second factor
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool   `json:"twoFactorRequired"`
+	ChallengeToken    string `json:"challengeToken"`
+}
+
+// TwoFactorLogin represents the request format to complete a login with a second factor
+// Either the TOTP code or a recovery code must be provided
+type TwoFactorLogin struct {
+	ChallengeToken string `json:"challengeToken"`
+	Code           string `json:"code,omitempty"`
+	RecoveryCode   string `json:"recoveryCode,omitempty"`
+}
@@ -17,16 +17,26 @@ type User struct {
 	Email     string    `json:"email,omitempty"`
 	Pass      string    `json:"pass,omitempty"`
 	CreatedAt time.Time `json:"createdAt,omitempty"`
+	// Set when the user confirms the email address (through the link sent by email)
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt,omitempty"`
+	// Role defines what the user is allowed to do (it can't be set by the user itself)
+	Role string `json:"role,omitempty"`
+	// Incremented whenever previously issued tokens must stop being accepted
+	TokenVersion uint64 `json:"-"`
+	// Whether a second factor (TOTP) is required on login
+	TwoFactorEnabled bool `json:"-"`
+	// Set when the user is deleted (it's kept until purged, so it can be restored)
+	DeletedAt *time.Time `json:"-"`
 }
 
 // Prepare method calls the other methods to adequate user instance for insertion on database
+// When registering, the password must be checked against the password policy (see security.ValidatePassword)
+// after preparing the user, and hashed with HashPassword
 func (user *User) Prepare(action string) error {
+	user.format()
 	if err := user.validate(action); err != nil {
 		return err
 	}
-	if err := user.format(action); err != nil {
-		return err
-	}
 	return nil
 }
 
@@ -54,22 +64,21 @@ func (user *User) validate(action string) error {
 }
 
 // format updates user fields, in order to meet the desired format
-func (user *User) format(action string) error {
+func (user *User) format() {
 	// Removing trailing/leading spaces
 	user.Name = strings.TrimSpace(user.Name)
 	user.Username = strings.TrimSpace(user.Username)
 	user.Email = strings.TrimSpace(user.Email)
+}
 
-	// When registering a new user
-	if action == "register" {
-		// Creating the hash for the user password
-		hashPass, err := security.Hash(user.Pass)
-		if err != nil {
-			return err
-		}
-		// If everything is ok, we'll save the hash pass for the user
-		user.Pass = string(hashPass)
+// HashPassword replaces the user password by its hash, for insertion on database
+func (user *User) HashPassword() error {
+	// Creating the hash for the user password
+	hashPass, err := security.Hash(user.Pass)
+	if err != nil {
+		return err
 	}
-
+	// If everything is ok, we'll save the hash pass for the user
+	user.Pass = string(hashPass)
 	return nil
 }
@@ -0,0 +1,142 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"errors"
+	"sync"
+)
+
+// Defining the errors returned by the in-memory repositories (the same constraints enforced by the database)
+var (
+	ErrDuplicateEntry      = errors.New("Duplicate entry: the value is already in use")
+	ErrForeignKeyViolation = errors.New("Foreign key constraint fails: the referenced row doesn't exist")
+)
+
+// follow represents an user (UserID) followed by another one (FollowerID)
+type follow struct {
+	UserID     uint64
+	FollowerID uint64
+}
+
+// like represents a post (PostID) liked by an user (UserID)
+type like struct {
+	PostID uint64
+	UserID uint64
+}
+
+// MemoryDatabase holds the data used by the in-memory repositories, stored on the application memory
+// It's safe for concurrent use, and the repositories sharing it see each other's changes (e.g. posts authors)
+type MemoryDatabase struct {
+	mutex      sync.RWMutex
+	users      map[uint64]*memoryUser
+	followers  map[follow]bool
+	posts      map[uint64]*memoryPost
+	likes      map[like]bool
+	lastUserID uint64
+	lastPostID uint64
+}
+
+// memoryUser represents an user stored on memory, along with the data not returned on models.User
+type memoryUser struct {
+	models.User
+	TwoFactor models.TwoFactor
+}
+
+// memoryPost represents a post stored on memory, along with its legacy likes (the likes given before they were
+// recorded by user, which are kept on the likes counter like on the database)
+type memoryPost struct {
+	models.Post
+	LegacyLikes uint64
+}
+
+// activeUser returns a specific user, or nil if it doesn't exist or was deleted
+// The mutex must be locked by the caller
+func (db *MemoryDatabase) activeUser(ID uint64) *memoryUser {
+	if user, ok := db.users[ID]; ok && user.DeletedAt == nil {
+		return user
+	}
+	return nil
+}
+
+// countLikes sets a post likes counter to the number of likes recorded for it, plus its legacy likes
+// The mutex must be locked by the caller
+func (db *MemoryDatabase) countLikes(post *memoryPost) {
+	post.Likes = post.LegacyLikes
+	for like := range db.likes {
+		if like.PostID == post.ID {
+			post.Likes++
+		}
+	}
+}
+
+// clone returns a copy of the data, which can be changed without affecting the original one
+// The mutex must be locked by the caller
+func (db *MemoryDatabase) clone() *MemoryDatabase {
+	clone := NewMemoryDatabase()
+	for ID, user := range db.users {
+		copied := *user
+		clone.users[ID] = &copied
+	}
+	for follow := range db.followers {
+		clone.followers[follow] = true
+	}
+	for ID, post := range db.posts {
+		copied := *post
+		clone.posts[ID] = &copied
+	}
+	for like := range db.likes {
+		clone.likes[like] = true
+	}
+	clone.lastUserID, clone.lastPostID = db.lastUserID, db.lastPostID
+	return clone
+}
+
+// NewMemoryDatabase instantiates/initializes an empty in-memory database
+func NewMemoryDatabase() *MemoryDatabase {
+	return &MemoryDatabase{
+		users:     make(map[uint64]*memoryUser),
+		followers: make(map[follow]bool),
+		posts:     make(map[uint64]*memoryPost),
+		likes:     make(map[like]bool),
+	}
+}
+
+// MemoryStore is the Store which keeps the data on the application memory (e.g. on tests)
+// Its units of work only provide the users and posts repositories, since the other data is kept on the database
+type MemoryStore struct {
+	db    *MemoryDatabase
+	users *MemoryUsers
+	posts *MemoryPosts
+}
+
+// NewMemoryStore instantiates/initializes a store on an in-memory database
+func NewMemoryStore(db *MemoryDatabase) *MemoryStore {
+	return &MemoryStore{db, NewMemoryUsersRepository(db), NewMemoryPostsRepository(db)}
+}
+
+// Users returns the users repository, outside of any unit of work
+func (store *MemoryStore) Users() UserStore {
+	return store.users
+}
+
+// Posts returns the posts repository, outside of any unit of work
+func (store *MemoryStore) Posts() PostStore {
+	return store.posts
+}
+
+// RunUnitOfWork runs a function with repositories on a copy of the data, which replaces the data if it succeeds
+// The data is locked until the function ends, so it must only use the unit repositories (like a transaction)
+func (store *MemoryStore) RunUnitOfWork(ctx context.Context, work func(unit UnitOfWork) error) error {
+	store.db.mutex.Lock()
+	defer store.db.mutex.Unlock()
+
+	// The changes are discarded along with the copy if the function returns an error (or panics)
+	clone := store.db.clone()
+	if err := work(UnitOfWork{Users: NewMemoryUsersRepository(clone), Posts: NewMemoryPostsRepository(clone)}); err != nil {
+		return err
+	}
+	store.db.users, store.db.followers, store.db.posts, store.db.likes = clone.users, clone.followers, clone.posts, clone.likes
+	store.db.lastUserID, store.db.lastPostID = clone.lastUserID, clone.lastPostID
+	return nil
+}
@@ -0,0 +1,17 @@
+package repositories
+
+import "testing"
+
+func TestMemoryLegacyLikes(t *testing.T) {
+	db := NewMemoryDatabase()
+	store := NewMemoryStore(db)
+	authorID := createTestUser(t, store, "author")
+	postID := createTestPost(t, store, authorID, "title", "content")
+
+	// The likes given before the likes were recorded by user are kept, and the new ones are added to them
+	db.posts[postID].LegacyLikes = 3
+	likePost(t, store, postID, authorID, true)
+	checkLikes(t, store, postID, authorID, 4, true)
+	dislikePost(t, store, postID, authorID, true)
+	checkLikes(t, store, postID, authorID, 3, false)
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"api/src/models"
+	"fmt"
+)
+
+// keyset returns the clauses which select a page of a list ordered by an ID column (descending or ascending):
+// the condition which skips the items up to the cursor (empty on the first page), to be appended to the query
+// conditions, and the order and limit clause, to be appended to the query. The arguments match both clauses
+// Previous pages are read in the opposite order from the cursor, and one extra row is read to know if there are
+// more items beyond the page (see pageItems)
+func keyset(column string, descending bool, page models.PageRequest) (string, string, []interface{}) {
+	readDescending := descending != page.Backward()
+	comparison, order := ">", "asc"
+	if readDescending {
+		comparison, order = "<", "desc"
+	}
+
+	var condition string
+	var args []interface{}
+	if cursor := pageCursor(page); cursor != 0 {
+		condition = fmt.Sprintf(" and %s %s ?", column, comparison)
+		args = append(args, cursor)
+	}
+	args = append(args, page.Limit+1)
+	return condition, fmt.Sprintf(" order by %s %s limit ?", column, order), args
+}
+
+// pageItems turns the rows read for a page (see keyset) into the page items, in the list order
+// It returns whether there are more items beyond the page, in the direction it was read
+func pageItems[T any](items []T, page models.PageRequest) ([]T, bool) {
+	more := len(items) > page.Limit
+	if more {
+		items = items[:page.Limit]
+	}
+
+	// Previous pages were read backwards
+	if page.Backward() {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+	}
+	return items, more
+}
+
+// memoryPage selects a page of a list already sorted in its order (descending or ascending IDs), like keyset
+// and pageItems do on the database
+func memoryPage[T any](items []T, ID func(item T) uint64, descending bool, page models.PageRequest) ([]T, bool) {
+	cursor := pageCursor(page)
+	readDescending := descending != page.Backward()
+
+	// Reading the items beyond the cursor, in the direction of the page
+	var read []T
+	for i := range items {
+		item := items[i]
+		if page.Backward() {
+			item = items[len(items)-1-i]
+		}
+		if cursor != 0 && (readDescending && ID(item) >= cursor || !readDescending && ID(item) <= cursor) {
+			continue
+		}
+		if read = append(read, item); len(read) > page.Limit {
+			break
+		}
+	}
+	return pageItems(read, page)
+}
+
+// pageCursor returns the ID of the item where the page starts or ends (zero on the first page)
+func pageCursor(page models.PageRequest) uint64 {
+	if page.Backward() {
+		return page.Before
+	}
+	return page.After
+}
@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"api/src/database"
+	"api/src/models"
+	"context"
+	"time"
+)
+
+// PasswordResetTokens represents a password reset tokens repository
+type PasswordResetTokens struct {
+	db database.Executor
+}
+
+// NewPasswordResetTokensRepository instantiates/initializes a password reset tokens repository
+func NewPasswordResetTokensRepository(db database.Executor) *PasswordResetTokens {
+	return &PasswordResetTokens{db}
+}
+
+// Create is a PasswordResetTokens' method to store new password reset tokens on the repository
+func (repository PasswordResetTokens) Create(ctx context.Context, token models.PasswordResetToken) (uint64, error) {
+	// Preparing the insert statment
+	statement, err := repository.db.PrepareInsertContext(ctx,
+		"insert into password_reset_tokens (user_id, token_hash, expires_at) values (?, ?, ?)",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer statement.Close()
+
+	// Executing the query to store the password reset token
+	ID, err := statement.InsertContext(ctx, token.UserID, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+
+	// Finally, we return the inserted token ID
+	return ID, nil
+}
+
+// SearchByHash a specific password reset token by its hash
+func (repository PasswordResetTokens) SearchByHash(ctx context.Context, tokenHash string) (models.PasswordResetToken, error) {
+	// Executing the select statement
+	rows, err := repository.db.QueryContext(ctx,
+		`select id, user_id, token_hash, expires_at, used_at, createdAt
+		from password_reset_tokens where token_hash = ?`,
+		tokenHash,
+	)
+	if err != nil {
+		// We return an empty token if an error occurs
+		return models.PasswordResetToken{}, err
+	}
+	defer rows.Close()
+
+	// Reading row data
+	var token models.PasswordResetToken
+	if rows.Next() {
+		// Getting token
+		if err = rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.TokenHash,
+			&token.ExpiresAt,
+			&token.UsedAt,
+			&token.CreatedAt,
+		); err != nil {
+			// We return an empty token if an error occurs
+			return models.PasswordResetToken{}, err
+		}
+	}
+
+	// Returning the token data
+	return token, nil
+}
+
+// Use marks a specific password reset token as used
+// It returns false if the token had already been used (e.g. by a concurrent request)
+func (repository PasswordResetTokens) Use(ctx context.Context, ID uint64) (bool, error) {
+	// Preparing the statement to execute the SQL query
+	// Only unused tokens are updated, so the token can be used just once
+	statement, err := repository.db.PrepareContext(ctx,
+		"update password_reset_tokens set used_at = ? where id = ? and used_at is null",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	result, err := statement.ExecContext(ctx, time.Now(), ID)
+	if err != nil {
+		return false, err
+	}
+
+	// Checking if the token was actually used by this call
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	// Returning the function
+	return rowsAffected == 1, nil
+}
+
+// RevokeByUser marks all unused password reset tokens from a specific user as used
+func (repository PasswordResetTokens) RevokeByUser(ctx context.Context, userID uint64) error {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update password_reset_tokens set used_at = ? where user_id = ? and used_at is null",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	if _, err = statement.ExecContext(ctx, time.Now(), userID); err != nil {
+		return err
+	}
+
+	// Returning the function
+	return nil
+}
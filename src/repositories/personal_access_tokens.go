@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"api/src/database"
+	"api/src/models"
+	"context"
+	"strings"
+	"time"
+)
+
+// PersonalAccessTokens represents a personal access tokens repository
+type PersonalAccessTokens struct {
+	db database.Executor
+}
+
+// NewPersonalAccessTokensRepository instantiates/initializes a personal access tokens repository
+func NewPersonalAccessTokensRepository(db database.Executor) *PersonalAccessTokens {
+	return &PersonalAccessTokens{db}
+}
+
+// Create is a PersonalAccessTokens' method to store new tokens on the repository
+func (repository PersonalAccessTokens) Create(ctx context.Context, token models.PersonalAccessToken) (uint64, error) {
+	// Preparing the insert statment
+	statement, err := repository.db.PrepareInsertContext(ctx,
+		"insert into personal_access_tokens (user_id, name, token_hash, scopes, expires_at) values (?, ?, ?, ?, ?)",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer statement.Close()
+
+	// Executing the query to store the token (scopes are stored as a comma separated list)
+	ID, err := statement.InsertContext(ctx,
+		token.UserID, token.Name, token.TokenHash, strings.Join(token.Scopes, ","), token.ExpiresAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Finally, we return the inserted token ID
+	return ID, nil
+}
+
+// SearchByUser returns a specific user active tokens (their hashes are not returned)
+func (repository PersonalAccessTokens) SearchByUser(ctx context.Context, userID uint64) ([]models.PersonalAccessToken, error) {
+	// Executing the select statement
+	rows, err := repository.db.QueryContext(ctx,
+		`select id, user_id, name, scopes, last_used_at, expires_at, createdAt
+		from personal_access_tokens where user_id = ? and revoked_at is null
+		order by id`,
+		userID,
+	)
+	if err != nil {
+		// We return an empty list if an error occurs
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Reading rows data
+	var tokens []models.PersonalAccessToken
+	for rows.Next() {
+		// Getting token
+		var token models.PersonalAccessToken
+		var scopes string
+		if err = rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Name,
+			&scopes,
+			&token.LastUsedAt,
+			&token.ExpiresAt,
+			&token.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		token.Scopes = strings.Split(scopes, ",")
+		// Appending to the tokens list
+		tokens = append(tokens, token)
+	}
+
+	// Returning the tokens slice
+	return tokens, nil
+}
+
+// SearchByHash a specific token by its hash
+func (repository PersonalAccessTokens) SearchByHash(ctx context.Context, tokenHash string) (models.PersonalAccessToken, error) {
+	// Executing the select statement
+	rows, err := repository.db.QueryContext(ctx,
+		`select id, user_id, name, scopes, last_used_at, expires_at, revoked_at, createdAt
+		from personal_access_tokens where token_hash = ?`,
+		tokenHash,
+	)
+	if err != nil {
+		// We return an empty token if an error occurs
+		return models.PersonalAccessToken{}, err
+	}
+	defer rows.Close()
+
+	// Reading row data
+	var token models.PersonalAccessToken
+	if rows.Next() {
+		// Getting token
+		var scopes string
+		if err = rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Name,
+			&scopes,
+			&token.LastUsedAt,
+			&token.ExpiresAt,
+			&token.RevokedAt,
+			&token.CreatedAt,
+		); err != nil {
+			// We return an empty token if an error occurs
+			return models.PersonalAccessToken{}, err
+		}
+		token.Scopes = strings.Split(scopes, ",")
+	}
+
+	// Returning the token data
+	return token, nil
+}
+
+// UpdateLastUsed registers the last time a specific token was used
+func (repository PersonalAccessTokens) UpdateLastUsed(ctx context.Context, ID uint64) error {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update personal_access_tokens set last_used_at = ? where id = ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	if _, err = statement.ExecContext(ctx, time.Now(), ID); err != nil {
+		return err
+	}
+
+	// Returning the function
+	return nil
+}
+
+// Revoke revokes a specific user token
+// It returns false if the token doesn't exist, belongs to another user or had already been revoked
+func (repository PersonalAccessTokens) Revoke(ctx context.Context, userID, ID uint64) (bool, error) {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update personal_access_tokens set revoked_at = ? where id = ? and user_id = ? and revoked_at is null",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	result, err := statement.ExecContext(ctx, time.Now(), ID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	// Checking if the token was actually revoked by this call
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	// Returning the function
+	return rowsAffected == 1, nil
+}
+
+// RevokeByUser revokes all active tokens from a specific user
+func (repository PersonalAccessTokens) RevokeByUser(ctx context.Context, userID uint64) error {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update personal_access_tokens set revoked_at = ? where user_id = ? and revoked_at is null",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	if _, err = statement.ExecContext(ctx, time.Now(), userID); err != nil {
+		return err
+	}
+
+	// Returning the function
+	return nil
+}
@@ -1,24 +1,29 @@
 package repositories
 
 import (
+	"api/src/database"
 	"api/src/models"
-	"database/sql"
+	"context"
+	"time"
 )
 
+// likedBy is the column which tells whether a post (aliased as p) is liked by an user (the query argument)
+const likedBy = "exists (select 1 from post_likes l where l.post_id = p.id and l.user_id = ?)"
+
 // Posts represents a posts repository
 type Posts struct {
-	db *sql.DB
+	db database.Executor
 }
 
 // NewPostsRepository instantiates/initializes a posts repository
-func NewPostsRepository(db *sql.DB) *Posts {
+func NewPostsRepository(db database.Executor) *Posts {
 	return &Posts{db}
 }
 
 // Create is a Posts' method to create new posts on the repository
-func (repository Posts) Create(post models.Post) (uint64, error) {
+func (repository Posts) Create(ctx context.Context, post models.Post) (uint64, error) {
 	// Preparing the insert statment
-	statement, err := repository.db.Prepare(
+	statement, err := repository.db.PrepareInsertContext(ctx,
 		"insert into posts (title, content, author_id) values (?, ?, ?)",
 	)
 	if err != nil {
@@ -27,34 +32,30 @@ func (repository Posts) Create(post models.Post) (uint64, error) {
 	defer statement.Close()
 
 	// Executing the query to create new post
-	result, err := statement.Exec(post.Title, post.Content, post.AuthorID)
-	if err != nil {
-		return 0, err
-	}
-
-	// Getting the last inserted post ID
-	lastInsertedId, err := result.LastInsertId()
+	ID, err := statement.InsertContext(ctx, post.Title, post.Content, post.AuthorID)
 	if err != nil {
 		return 0, err
 	}
 
 	// Finally, we return the inserted post ID
-	return uint64(lastInsertedId), nil
+	return ID, nil
 }
 
-// Search posts from user and users followed by the user
-func (repository Posts) Search(userID uint64) ([]models.Post, error) {
+// Search a page of the posts from user and users followed by the user (newest first)
+// It also returns whether there are more posts beyond the page
+func (repository Posts) Search(ctx context.Context, userID uint64, page models.PageRequest) ([]models.Post, bool, error) {
 	// Executing the select statement
-	rows, err := repository.db.Query(
-		`select distinct p.*, u.username from posts p
+	// Followed authors are checked with a subquery, so each post is read once and the page can be limited
+	condition, order, pageArgs := keyset("p.id", true, page)
+	rows, err := repository.db.QueryContext(ctx,
+		`select p.id, p.title, p.content, p.author_id, p.likes, p.createdAt, u.username, `+likedBy+` from posts p
 		inner join users u on u.id = p.author_id
-		inner join followers f on p.author_id = f.user_id
-		where p.author_id = ? or f.follower_id = ?
-		order by 1 desc;`,
-		userID, userID,
+		where (p.author_id = ? or p.author_id in (select user_id from followers where follower_id = ?))
+		and p.deleted_at is null and u.deleted_at is null`+condition+order,
+		append([]interface{}{userID, userID, userID}, pageArgs...)...,
 	)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	defer rows.Close()
 
@@ -71,26 +72,28 @@ func (repository Posts) Search(userID uint64) ([]models.Post, error) {
 			&post.Likes,
 			&post.CreatedAt,
 			&post.AuthorUsername,
+			&post.LikedByMe,
 		); err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		// Appending to the posts list
 		posts = append(posts, post)
 	}
 
-	// Returning the posts slice
-	return posts, nil
+	// Returning the posts page
+	posts, more := pageItems(posts, page)
+	return posts, more, rows.Err()
 }
 
-// SearchByID a specific post by its ID
-func (repository Posts) SearchByID(postID uint64) (models.Post, error) {
+// SearchByID a specific post by its ID, telling whether the viewer (the user searching it) likes it
+func (repository Posts) SearchByID(ctx context.Context, postID, viewerID uint64) (models.Post, error) {
 	// Executing the select statement
-	rows, err := repository.db.Query(
-		`select p.*, u.username from
+	rows, err := repository.db.QueryContext(ctx,
+		`select p.id, p.title, p.content, p.author_id, p.likes, p.createdAt, u.username, `+likedBy+` from
 		posts p inner join users u
 		on u.id = p.author_id
-		where p.ID = ?`,
-		postID,
+		where p.ID = ? and p.deleted_at is null and u.deleted_at is null`,
+		viewerID, postID,
 	)
 	if err != nil {
 		// We return an empty post if an error occurs
@@ -110,6 +113,7 @@ func (repository Posts) SearchByID(postID uint64) (models.Post, error) {
 			&post.Likes,
 			&post.CreatedAt,
 			&post.AuthorUsername,
+			&post.LikedByMe,
 		); err != nil {
 			// We return an empty post if an error occurs
 			return models.Post{}, err
@@ -120,11 +124,65 @@ func (repository Posts) SearchByID(postID uint64) (models.Post, error) {
 	return post, nil
 }
 
+// SearchByIDForUpdate a specific post by its ID, locking it until the transaction ends
+// It must be used inside a unit of work, so concurrent changes to the post wait for the lock
+// Only the post columns are read (the author username isn't), since joined rows would be locked too
+func (repository Posts) SearchByIDForUpdate(ctx context.Context, postID uint64) (models.Post, error) {
+	return repository.searchForUpdate(ctx, postID, false)
+}
+
+// SearchDeletedByIDForUpdate a specific deleted post by its ID, locking it until the transaction ends
+// It must be used inside a unit of work, like SearchByIDForUpdate
+func (repository Posts) SearchDeletedByIDForUpdate(ctx context.Context, postID uint64) (models.Post, error) {
+	return repository.searchForUpdate(ctx, postID, true)
+}
+
+// searchForUpdate searchs a specific post (either active or deleted) by its ID, locking it until the transaction ends
+func (repository Posts) searchForUpdate(ctx context.Context, postID uint64, deleted bool) (models.Post, error) {
+	condition := "deleted_at is null"
+	if deleted {
+		condition = "deleted_at is not null"
+	}
+
+	// Executing the select statement
+	rows, err := repository.db.QueryContext(ctx,
+		"select id, title, content, author_id, likes, createdAt, deleted_at from posts where id = ? and "+condition+
+			repository.db.Dialect().ForUpdate(),
+		postID,
+	)
+	if err != nil {
+		// We return an empty post if an error occurs
+		return models.Post{}, err
+	}
+	defer rows.Close()
+
+	// Reading row data
+	var post models.Post
+	if rows.Next() {
+		// Getting post
+		if err = rows.Scan(
+			&post.ID,
+			&post.Title,
+			&post.Content,
+			&post.AuthorID,
+			&post.Likes,
+			&post.CreatedAt,
+			&post.DeletedAt,
+		); err != nil {
+			// We return an empty post if an error occurs
+			return models.Post{}, err
+		}
+	}
+
+	// Returning the post data
+	return post, rows.Err()
+}
+
 // Update will edit a specific post data by its ID
-func (repository Posts) Update(ID uint64, post models.Post) error {
+func (repository Posts) Update(ctx context.Context, ID uint64, post models.Post) error {
 	// Preparing the statement to execute the SQL query
-	statement, err := repository.db.Prepare(
-		"update posts set title = ?, content = ? where id = ?",
+	statement, err := repository.db.PrepareContext(ctx,
+		"update posts set title = ?, content = ? where id = ? and deleted_at is null",
 	)
 	if err != nil {
 		return err
@@ -132,7 +190,7 @@ func (repository Posts) Update(ID uint64, post models.Post) error {
 	defer statement.Close()
 
 	// Executing the update statement
-	if _, err = statement.Exec(post.Title, post.Content, ID); err != nil {
+	if _, err = statement.ExecContext(ctx, post.Title, post.Content, ID); err != nil {
 		return err
 	}
 
@@ -140,17 +198,19 @@ func (repository Posts) Update(ID uint64, post models.Post) error {
 	return nil
 }
 
-// Delete removes a specific post from the database
-func (repository Posts) Delete(ID uint64) error {
+// Delete marks a specific post as deleted, hiding it until it's restored or purged
+func (repository Posts) Delete(ctx context.Context, ID uint64) error {
 	// Preparing the statement to execute the SQL query
-	statement, err := repository.db.Prepare("delete from posts where id = ?")
+	statement, err := repository.db.PrepareContext(ctx,
+		"update posts set deleted_at = ? where id = ? and deleted_at is null",
+	)
 	if err != nil {
 		return err
 	}
 	defer statement.Close()
 
 	// Executing the delete statement
-	if _, err = statement.Exec(ID); err != nil {
+	if _, err = statement.ExecContext(ctx, time.Now(), ID); err != nil {
 		return err
 	}
 
@@ -158,18 +218,62 @@ func (repository Posts) Delete(ID uint64) error {
 	return nil
 }
 
-// SearchByUser returns a specific user posts
-func (repository Posts) SearchByUser(userID uint64) ([]models.Post, error) {
+// Restore brings back a specific deleted post
+// It returns false if the post doesn't exist or isn't deleted
+func (repository Posts) Restore(ctx context.Context, ID uint64) (bool, error) {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update posts set deleted_at = null where id = ? and deleted_at is not null",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer statement.Close()
+
+	// Executing the restore statement
+	result, err := statement.ExecContext(ctx, ID)
+	if err != nil {
+		return false, err
+	}
+
+	// Checking if the post was actually restored
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	// Returning the function
+	return rowsAffected == 1, nil
+}
+
+// Purge permanently removes the posts deleted before the provided time, returning how many were removed
+func (repository Posts) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	// Executing the delete statement
+	result, err := repository.db.ExecContext(ctx,
+		"delete from posts where deleted_at is not null and deleted_at < ?", deletedBefore,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Returning how many posts were removed
+	return result.RowsAffected()
+}
+
+// SearchByUser returns a page of a specific user posts (newest first), telling which ones the viewer likes
+// It also returns whether there are more posts beyond the page
+func (repository Posts) SearchByUser(ctx context.Context, userID, viewerID uint64, page models.PageRequest) ([]models.Post, bool, error) {
 	// Executing the select statement
-	rows, err := repository.db.Query(
-		`select p.*, u.username from posts p
+	condition, order, pageArgs := keyset("p.id", true, page)
+	rows, err := repository.db.QueryContext(ctx,
+		`select p.id, p.title, p.content, p.author_id, p.likes, p.createdAt, u.username, `+likedBy+` from posts p
 		inner join users u on u.id = p.author_id
-		where p.author_id = ?`,
-		userID,
+		where p.author_id = ? and p.deleted_at is null and u.deleted_at is null`+condition+order,
+		append([]interface{}{viewerID, userID}, pageArgs...)...,
 	)
 	if err != nil {
 		// We return an empty list if an error occurs
-		return nil, err
+		return nil, false, err
 	}
 	defer rows.Close()
 
@@ -186,58 +290,91 @@ func (repository Posts) SearchByUser(userID uint64) ([]models.Post, error) {
 			&post.Likes,
 			&post.CreatedAt,
 			&post.AuthorUsername,
+			&post.LikedByMe,
 		); err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		// Appending to the posts list
 		posts = append(posts, post)
 	}
 
-	// Returning the posts slice
-	return posts, nil
+	// Returning the posts page
+	posts, more := pageItems(posts, page)
+	return posts, more, rows.Err()
 }
 
-// Like will add 1 to the number of likes in a post
-func (repository Posts) Like(postID uint64) error {
-	// Preparing the statement to execute the SQL query
-	statement, err := repository.db.Prepare(
-		"update posts set likes = likes + 1 where id = ?",
+// Like records the user like on a (not deleted) post, returning false if the user already likes it
+// The post likes counter is updated along with it, so it must be used inside a unit of work (with the post locked)
+func (repository Posts) Like(ctx context.Context, postID, userID uint64) (bool, error) {
+	// Preparing the insert statment
+	// We'll ignore the insertion of duplicate entries, and deleted posts can't be liked
+	statement, err := repository.db.PrepareContext(ctx,
+		repository.db.Dialect().InsertIgnore(
+			"insert into post_likes (post_id, user_id) select id, ? from posts where id = ? and deleted_at is null",
+		),
 	)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer statement.Close()
 
-	// Executing the update statement
-	if _, err = statement.Exec(postID); err != nil {
-		return err
+	// Executing the query to like the post
+	result, err := statement.ExecContext(ctx, userID, postID)
+	if err != nil {
+		return false, err
 	}
 
-	// Returning the function
-	return nil
+	// Checking if the like was actually recorded
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	// Returning the function, after updating the likes counter
+	return true, repository.countLikes(ctx, postID)
 }
 
-// Dislike will subtract 1 from the number of likes in a post
-func (repository Posts) Dislike(postID uint64) error {
-	// Preparing the statement to execute the SQL query
-	statement, err := repository.db.Prepare(
-		`update posts set likes = 
-		CASE
-			WHEN likes > 0 THEN likes - 1
-			ELSE 0
-		END
-		where id = ?`,
+// Dislike removes the user like from a post, returning false if the user didn't like it
+// The post likes counter is updated along with it, so it must be used inside a unit of work (with the post locked)
+func (repository Posts) Dislike(ctx context.Context, postID, userID uint64) (bool, error) {
+	// Preparing the delete statment
+	statement, err := repository.db.PrepareContext(ctx,
+		"delete from post_likes where post_id = ? and user_id = ?",
 	)
 	if err != nil {
-		return err
+		return false, err
 	}
 	defer statement.Close()
 
-	// Executing the update statement
-	if _, err = statement.Exec(postID); err != nil {
-		return err
+	// Executing the query to remove the like
+	result, err := statement.ExecContext(ctx, postID, userID)
+	if err != nil {
+		return false, err
 	}
 
-	// Returning the function
-	return nil
+	// Checking if the like was actually removed
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	// Returning the function, after updating the likes counter
+	return true, repository.countLikes(ctx, postID)
+}
+
+// countLikes sets the post likes counter to the number of likes recorded for it,
+// plus its legacy likes (given before the likes were recorded by user)
+func (repository Posts) countLikes(ctx context.Context, postID uint64) error {
+	// Executing the update statement
+	_, err := repository.db.ExecContext(ctx,
+		"update posts set likes = legacy_likes + (select count(*) from post_likes where post_id = ?) where id = ?",
+		postID, postID,
+	)
+	return err
 }
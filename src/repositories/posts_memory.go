@@ -0,0 +1,227 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"sort"
+	"time"
+)
+
+// MemoryPosts represents a posts repository, stored on the application memory
+// It has the same behavior as the database repository, so it can replace it (e.g. on tests)
+type MemoryPosts struct {
+	db *MemoryDatabase
+}
+
+// NewMemoryPostsRepository instantiates/initializes an in-memory posts repository
+func NewMemoryPostsRepository(db *MemoryDatabase) *MemoryPosts {
+	return &MemoryPosts{db}
+}
+
+// Create is a MemoryPosts' method to create new posts on the repository
+func (repository MemoryPosts) Create(ctx context.Context, post models.Post) (uint64, error) {
+	repository.db.mutex.Lock()
+	defer repository.db.mutex.Unlock()
+
+	// The author must exist
+	if repository.db.users[post.AuthorID] == nil {
+		return 0, ErrForeignKeyViolation
+	}
+
+	// Storing the post with the database defaults
+	repository.db.lastPostID++
+	repository.db.posts[repository.db.lastPostID] = &memoryPost{Post: models.Post{
+		ID:        repository.db.lastPostID,
+		Title:     post.Title,
+		Content:   post.Content,
+		AuthorID:  post.AuthorID,
+		CreatedAt: time.Now(),
+	}}
+
+	// Finally, we return the inserted post ID
+	return repository.db.lastPostID, nil
+}
+
+// Search a page of the posts from user and users followed by the user (newest first)
+// It also returns whether there are more posts beyond the page
+func (repository MemoryPosts) Search(ctx context.Context, userID uint64, page models.PageRequest) ([]models.Post, bool, error) {
+	repository.db.mutex.RLock()
+	defer repository.db.mutex.RUnlock()
+
+	posts, more := repository.page(func(post *memoryPost) bool {
+		return post.AuthorID == userID || repository.db.followers[follow{post.AuthorID, userID}]
+	}, userID, page)
+	return posts, more, nil
+}
+
+// SearchByID a specific post by its ID, telling whether the viewer (the user searching it) likes it
+func (repository MemoryPosts) SearchByID(ctx context.Context, postID, viewerID uint64) (models.Post, error) {
+	repository.db.mutex.RLock()
+	defer repository.db.mutex.RUnlock()
+
+	if post, ok := repository.db.posts[postID]; ok && repository.isVisible(post) {
+		return repository.view(post, viewerID), nil
+	}
+	return models.Post{}, nil
+}
+
+// SearchByIDForUpdate a specific post by its ID
+// On memory, units of work hold the lock on all the data, so the post doesn't need its own lock
+func (repository MemoryPosts) SearchByIDForUpdate(ctx context.Context, postID uint64) (models.Post, error) {
+	return repository.searchForUpdate(postID, false), nil
+}
+
+// SearchDeletedByIDForUpdate a specific deleted post by its ID
+// On memory, units of work hold the lock on all the data, so the post doesn't need its own lock
+func (repository MemoryPosts) SearchDeletedByIDForUpdate(ctx context.Context, postID uint64) (models.Post, error) {
+	return repository.searchForUpdate(postID, true), nil
+}
+
+// Update will edit a specific post data by its ID
+func (repository MemoryPosts) Update(ctx context.Context, ID uint64, post models.Post) error {
+	return repository.update(ID, func(savedPost *memoryPost) {
+		savedPost.Title = post.Title
+		savedPost.Content = post.Content
+	})
+}
+
+// Delete marks a specific post as deleted, hiding it until it's restored or purged
+func (repository MemoryPosts) Delete(ctx context.Context, ID uint64) error {
+	return repository.update(ID, func(post *memoryPost) {
+		now := time.Now()
+		post.DeletedAt = &now
+	})
+}
+
+// Restore brings back a specific deleted post
+// It returns false if the post doesn't exist or isn't deleted
+func (repository MemoryPosts) Restore(ctx context.Context, ID uint64) (bool, error) {
+	repository.db.mutex.Lock()
+	defer repository.db.mutex.Unlock()
+
+	post, ok := repository.db.posts[ID]
+	if !ok || post.DeletedAt == nil {
+		return false, nil
+	}
+	post.DeletedAt = nil
+	return true, nil
+}
+
+// Purge permanently removes the posts deleted before the provided time, returning how many were removed
+func (repository MemoryPosts) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	repository.db.mutex.Lock()
+	defer repository.db.mutex.Unlock()
+
+	var purged int64
+	for ID, post := range repository.db.posts {
+		if post.DeletedAt != nil && post.DeletedAt.Before(deletedBefore) {
+			delete(repository.db.posts, ID)
+			for like := range repository.db.likes {
+				if like.PostID == ID {
+					delete(repository.db.likes, like)
+				}
+			}
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// SearchByUser returns a page of a specific user posts (newest first), telling which ones the viewer likes
+// It also returns whether there are more posts beyond the page
+func (repository MemoryPosts) SearchByUser(ctx context.Context, userID, viewerID uint64, page models.PageRequest) ([]models.Post, bool, error) {
+	repository.db.mutex.RLock()
+	defer repository.db.mutex.RUnlock()
+
+	posts, more := repository.page(func(post *memoryPost) bool {
+		return post.AuthorID == userID
+	}, viewerID, page)
+	return posts, more, nil
+}
+
+// Like records the user like on a (not deleted) post, returning false if the user already likes it
+// The post likes counter is updated along with it
+func (repository MemoryPosts) Like(ctx context.Context, postID, userID uint64) (bool, error) {
+	repository.db.mutex.Lock()
+	defer repository.db.mutex.Unlock()
+
+	// The user must exist, and deleted posts can't be liked
+	if repository.db.users[userID] == nil {
+		return false, ErrForeignKeyViolation
+	}
+	post, ok := repository.db.posts[postID]
+	if !ok || post.DeletedAt != nil || repository.db.likes[like{postID, userID}] {
+		return false, nil
+	}
+	repository.db.likes[like{postID, userID}] = true
+	repository.db.countLikes(post)
+	return true, nil
+}
+
+// Dislike removes the user like from a post, returning false if the user didn't like it
+// The post likes counter is updated along with it
+func (repository MemoryPosts) Dislike(ctx context.Context, postID, userID uint64) (bool, error) {
+	repository.db.mutex.Lock()
+	defer repository.db.mutex.Unlock()
+
+	if !repository.db.likes[like{postID, userID}] {
+		return false, nil
+	}
+	delete(repository.db.likes, like{postID, userID})
+	if post, ok := repository.db.posts[postID]; ok {
+		repository.db.countLikes(post)
+	}
+	return true, nil
+}
+
+// page returns a page of the (visible) posts matching the condition, as seen by the viewer, newest first
+// It also returns whether there are more posts beyond the page. The mutex must be locked by the caller
+func (repository MemoryPosts) page(matches func(post *memoryPost) bool, viewerID uint64, page models.PageRequest) ([]models.Post, bool) {
+	var posts []models.Post
+	for _, post := range repository.db.posts {
+		if repository.isVisible(post) && matches(post) {
+			posts = append(posts, repository.view(post, viewerID))
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].ID > posts[j].ID })
+	return memoryPage(posts, func(post models.Post) uint64 { return post.ID }, true, page)
+}
+
+// update changes a specific post, if it exists (and wasn't deleted)
+func (repository MemoryPosts) update(ID uint64, change func(post *memoryPost)) error {
+	repository.db.mutex.Lock()
+	defer repository.db.mutex.Unlock()
+
+	if post, ok := repository.db.posts[ID]; ok && post.DeletedAt == nil {
+		change(post)
+	}
+	return nil
+}
+
+// searchForUpdate searchs a specific post (either active or deleted) by its ID
+func (repository MemoryPosts) searchForUpdate(ID uint64, deleted bool) models.Post {
+	repository.db.mutex.RLock()
+	defer repository.db.mutex.RUnlock()
+
+	if post, ok := repository.db.posts[ID]; ok && (post.DeletedAt != nil) == deleted {
+		return post.Post
+	}
+	return models.Post{}
+}
+
+// isVisible checks if a post and its author weren't deleted
+// The mutex must be locked by the caller
+func (repository MemoryPosts) isVisible(post *memoryPost) bool {
+	return post.DeletedAt == nil && repository.db.activeUser(post.AuthorID) != nil
+}
+
+// view returns a copy of the post, with its author username and whether the viewer likes it
+// The mutex must be locked by the caller
+func (repository MemoryPosts) view(post *memoryPost, viewerID uint64) models.Post {
+	result := post.Post
+	if author, ok := repository.db.users[post.AuthorID]; ok {
+		result.AuthorUsername = author.Username
+	}
+	result.LikedByMe = repository.db.likes[like{post.ID, viewerID}]
+	return result
+}
@@ -0,0 +1,85 @@
+package repositories
+
+import (
+	"api/src/database"
+	"api/src/models"
+	"context"
+)
+
+// PostsSearch represents a posts search repository, which uses the database full-text index
+type PostsSearch struct {
+	db database.Executor
+}
+
+// NewPostsSearchRepository instantiates/initializes a posts search repository
+func NewPostsSearchRepository(db database.Executor) *PostsSearch {
+	return &PostsSearch{db}
+}
+
+// Search returns the posts matching a search (most relevant first), telling which ones the viewer likes
+func (repository PostsSearch) Search(ctx context.Context, search models.PostSearch, viewerID uint64) ([]models.PostSearchResult, error) {
+	// Applying the search filters
+	var filters string
+	args := []interface{}{viewerID}
+	if search.AuthorID != 0 {
+		filters += " and p.author_id = ?"
+		args = append(args, search.AuthorID)
+	}
+	if !search.From.IsZero() {
+		filters += " and p.createdAt >= ?"
+		args = append(args, search.From)
+	}
+	if !search.To.IsZero() {
+		filters += " and p.createdAt < ?"
+		args = append(args, search.To)
+	}
+
+	// Matching the terms on the full-text index (its arguments come after the filters ones)
+	terms := make([]database.FullTextTerm, len(search.Terms))
+	for i, term := range search.Terms {
+		terms[i] = database.FullTextTerm(term)
+	}
+	join, condition, relevance, searchArgs := repository.db.Dialect().FullTextSearch(
+		"posts", "p", []string{"title", "content"}, terms,
+	)
+	args = append(append(args, searchArgs...), search.Limit)
+
+	// Executing the select statement
+	rows, err := repository.db.QueryContext(ctx,
+		`select p.id, p.title, p.content, p.author_id, p.likes, p.createdAt, u.username, `+likedBy+` from posts p
+		inner join users u on u.id = p.author_id`+join+`
+		where p.deleted_at is null and u.deleted_at is null`+filters+` and `+condition+`
+		order by `+relevance+` desc, p.id desc limit ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Reading rows data
+	var results []models.PostSearchResult
+	for rows.Next() {
+		// Getting post
+		var result models.PostSearchResult
+		if err = rows.Scan(
+			&result.ID,
+			&result.Title,
+			&result.Content,
+			&result.AuthorID,
+			&result.Likes,
+			&result.CreatedAt,
+			&result.AuthorUsername,
+			&result.LikedByMe,
+		); err != nil {
+			return nil, err
+		}
+		// Highlighting the matching words
+		result.Snippet = searchSnippet(result.Content, search.Terms)
+		// Appending to the results list
+		results = append(results, result)
+	}
+
+	// Returning the results
+	return results, rows.Err()
+}
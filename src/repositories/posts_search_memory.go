@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"sort"
+)
+
+// MemoryPostsSearch represents a posts search repository, over the posts stored on the application memory
+// It has the same behavior as the database repository, so it can replace it (e.g. on tests)
+type MemoryPostsSearch struct {
+	db *MemoryDatabase
+}
+
+// NewMemoryPostsSearchRepository instantiates/initializes an in-memory posts search repository
+func NewMemoryPostsSearchRepository(db *MemoryDatabase) *MemoryPostsSearch {
+	return &MemoryPostsSearch{db}
+}
+
+// Search returns the posts matching a search (most relevant first), telling which ones the viewer likes
+// The relevance is the number of matches on the post title and content
+func (repository MemoryPostsSearch) Search(ctx context.Context, search models.PostSearch, viewerID uint64) ([]models.PostSearchResult, error) {
+	repository.db.mutex.RLock()
+	defer repository.db.mutex.RUnlock()
+
+	posts := MemoryPosts{repository.db}
+	var results []models.PostSearchResult
+	relevance := make(map[uint64]int)
+	for _, post := range repository.db.posts {
+		// Applying the search filters
+		if !posts.isVisible(post) ||
+			search.AuthorID != 0 && post.AuthorID != search.AuthorID ||
+			!search.From.IsZero() && post.CreatedAt.Before(search.From) ||
+			!search.To.IsZero() && !post.CreatedAt.Before(search.To) {
+			continue
+		}
+
+		// Matching the terms (all of them must be found)
+		matches := searchMatches(search.Terms, post.Title, post.Content)
+		if matches == 0 {
+			continue
+		}
+		relevance[post.ID] = matches
+		results = append(results, models.PostSearchResult{
+			Post:    posts.view(post, viewerID),
+			Snippet: searchSnippet(post.Content, search.Terms),
+		})
+	}
+
+	// Sorting the results by relevance (and then newest first), up to the limit
+	sort.Slice(results, func(i, j int) bool {
+		if relevance[results[i].ID] != relevance[results[j].ID] {
+			return relevance[results[i].ID] > relevance[results[j].ID]
+		}
+		return results[i].ID > results[j].ID
+	})
+	if len(results) > search.Limit {
+		results = results[:search.Limit]
+	}
+	return results, nil
+}
+
+// searchMatches counts how many times the terms match the texts, or returns 0 if any of them doesn't match
+func searchMatches(terms []models.SearchTerm, texts ...string) int {
+	var words [][]searchWord
+	for _, text := range texts {
+		words = append(words, splitSearchWords(text))
+	}
+
+	total := 0
+	for _, term := range terms {
+		matches := 0
+		for _, textWords := range words {
+			for i := range textWords {
+				if matchesAt(term, textWords, i) {
+					matches++
+				}
+			}
+		}
+		if matches == 0 {
+			return 0
+		}
+		total += matches
+	}
+	return total
+}
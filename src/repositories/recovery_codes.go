@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"api/src/database"
+	"api/src/models"
+	"context"
+	"time"
+)
+
+// RecoveryCodes represents a two-factor recovery codes repository
+type RecoveryCodes struct {
+	db database.Executor
+}
+
+// NewRecoveryCodesRepository instantiates/initializes a recovery codes repository
+func NewRecoveryCodesRepository(db database.Executor) *RecoveryCodes {
+	return &RecoveryCodes{db}
+}
+
+// Replace removes a specific user recovery codes and stores the new ones (only their hashes)
+func (repository RecoveryCodes) Replace(ctx context.Context, userID uint64, codeHashes []string) error {
+	// Removing the previous codes
+	if err := repository.DeleteByUser(ctx, userID); err != nil {
+		return err
+	}
+
+	// Preparing the insert statment
+	statement, err := repository.db.PrepareContext(ctx,
+		"insert into recovery_codes (user_id, code_hash) values (?, ?)",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the query to store each code
+	for _, codeHash := range codeHashes {
+		if _, err = statement.ExecContext(ctx, userID, codeHash); err != nil {
+			return err
+		}
+	}
+
+	// If everything is ok, no error will be returned
+	return nil
+}
+
+// SearchUnused returns a specific user recovery codes which weren't used yet
+func (repository RecoveryCodes) SearchUnused(ctx context.Context, userID uint64) ([]models.RecoveryCode, error) {
+	// Executing the select statement
+	rows, err := repository.db.QueryContext(ctx,
+		"select id, code_hash from recovery_codes where user_id = ? and used_at is null", userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Reading rows data
+	var recoveryCodes []models.RecoveryCode
+	for rows.Next() {
+		// Getting code
+		var recoveryCode models.RecoveryCode
+		if err = rows.Scan(&recoveryCode.ID, &recoveryCode.CodeHash); err != nil {
+			return nil, err
+		}
+		// Appending to the codes list
+		recoveryCodes = append(recoveryCodes, recoveryCode)
+	}
+
+	// Returning the codes
+	return recoveryCodes, rows.Err()
+}
+
+// Use marks a specific recovery code as used
+// It returns false if the code doesn't exist or had already been used
+func (repository RecoveryCodes) Use(ctx context.Context, ID uint64) (bool, error) {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update recovery_codes set used_at = ? where id = ? and used_at is null",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	result, err := statement.ExecContext(ctx, time.Now(), ID)
+	if err != nil {
+		return false, err
+	}
+
+	// Checking if the code was actually used by this call
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	// Returning the function
+	return rowsAffected == 1, nil
+}
+
+// DeleteByUser removes all recovery codes from a specific user
+func (repository RecoveryCodes) DeleteByUser(ctx context.Context, userID uint64) error {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx, "delete from recovery_codes where user_id = ?")
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the delete statement
+	if _, err = statement.ExecContext(ctx, userID); err != nil {
+		return err
+	}
+
+	// Returning the function
+	return nil
+}
@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"api/src/database"
+	"api/src/models"
+	"context"
+	"time"
+)
+
+// RefreshTokens represents a refresh tokens repository
+type RefreshTokens struct {
+	db database.Executor
+}
+
+// NewRefreshTokensRepository instantiates/initializes a refresh tokens repository
+func NewRefreshTokensRepository(db database.Executor) *RefreshTokens {
+	return &RefreshTokens{db}
+}
+
+// Create is a RefreshTokens' method to store new refresh tokens on the repository
+func (repository RefreshTokens) Create(ctx context.Context, token models.RefreshToken) (uint64, error) {
+	// Preparing the insert statment
+	statement, err := repository.db.PrepareInsertContext(ctx,
+		"insert into refresh_tokens (user_id, family_id, token_hash, expires_at) values (?, ?, ?, ?)",
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer statement.Close()
+
+	// Executing the query to store the refresh token
+	ID, err := statement.InsertContext(ctx, token.UserID, token.FamilyID, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+
+	// Finally, we return the inserted token ID
+	return ID, nil
+}
+
+// SearchByHash a specific refresh token by its hash
+func (repository RefreshTokens) SearchByHash(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	// Executing the select statement
+	rows, err := repository.db.QueryContext(ctx,
+		`select id, user_id, family_id, token_hash, expires_at, revoked_at, createdAt
+		from refresh_tokens where token_hash = ?`,
+		tokenHash,
+	)
+	if err != nil {
+		// We return an empty token if an error occurs
+		return models.RefreshToken{}, err
+	}
+	defer rows.Close()
+
+	// Reading row data
+	var token models.RefreshToken
+	if rows.Next() {
+		// Getting token
+		if err = rows.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.FamilyID,
+			&token.TokenHash,
+			&token.ExpiresAt,
+			&token.RevokedAt,
+			&token.CreatedAt,
+		); err != nil {
+			// We return an empty token if an error occurs
+			return models.RefreshToken{}, err
+		}
+	}
+
+	// Returning the token data
+	return token, nil
+}
+
+// Revoke marks a specific refresh token as used/revoked
+// It returns false if the token had already been revoked (e.g. by a concurrent request)
+func (repository RefreshTokens) Revoke(ctx context.Context, ID uint64) (bool, error) {
+	// Preparing the statement to execute the SQL query
+	// Only active tokens are updated, so the token can be used just once
+	statement, err := repository.db.PrepareContext(ctx,
+		"update refresh_tokens set revoked_at = ? where id = ? and revoked_at is null",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	result, err := statement.ExecContext(ctx, time.Now(), ID)
+	if err != nil {
+		return false, err
+	}
+
+	// Checking if the token was actually revoked by this call
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	// Returning the function
+	return rowsAffected == 1, nil
+}
+
+// RevokeFamily revokes all active refresh tokens from a specific family
+func (repository RefreshTokens) RevokeFamily(ctx context.Context, familyID string) error {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update refresh_tokens set revoked_at = ? where family_id = ? and revoked_at is null",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	if _, err = statement.ExecContext(ctx, time.Now(), familyID); err != nil {
+		return err
+	}
+
+	// Returning the function
+	return nil
+}
+
+// RevokeByUser revokes all active refresh tokens from a specific user
+func (repository RefreshTokens) RevokeByUser(ctx context.Context, userID uint64) error {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update refresh_tokens set revoked_at = ? where user_id = ? and revoked_at is null",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	if _, err = statement.ExecContext(ctx, time.Now(), userID); err != nil {
+		return err
+	}
+
+	// Returning the function
+	return nil
+}
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"api/src/database"
+	"context"
+	"time"
+)
+
+// RevokedTokens represents a revoked tokens repository, stored on the database
+type RevokedTokens struct {
+	db database.Executor
+}
+
+// NewRevokedTokensRepository instantiates/initializes a revoked tokens repository
+func NewRevokedTokensRepository(db database.Executor) *RevokedTokens {
+	return &RevokedTokens{db}
+}
+
+// Revoke invalidates a single token (by its ID) until it expires
+func (repository RevokedTokens) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	// Removing tokens which already expired, since they're not accepted anymore
+	if _, err := repository.db.ExecContext(ctx, "delete from revoked_tokens where expires_at < ?", time.Now()); err != nil {
+		return err
+	}
+
+	// Preparing the insert statment
+	// We'll ignore the insertion of duplicate entries
+	statement, err := repository.db.PrepareContext(ctx,
+		repository.db.Dialect().InsertIgnore("insert into revoked_tokens (token_id, expires_at) values (?, ?)"),
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the query to revoke the token
+	if _, err = statement.ExecContext(ctx, tokenID, expiresAt); err != nil {
+		return err
+	}
+
+	// If everything is ok, no error will be returned
+	return nil
+}
+
+// RevokeAllByUser invalidates all tokens issued to an user before the provided time
+func (repository RevokedTokens) RevokeAllByUser(ctx context.Context, userID uint64, issuedBefore time.Time) error {
+	// Preparing the insert statment
+	// If the user already had revoked tokens, the time is updated
+	statement, err := repository.db.PrepareContext(ctx,
+		repository.db.Dialect().Upsert(
+			"insert into revoked_users (user_id, revoked_before) values (?, ?)",
+			[]string{"user_id"}, "revoked_before",
+		),
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the query to revoke the user tokens
+	// Tokens issue time has seconds precision, so the time is truncated as well
+	if _, err = statement.ExecContext(ctx, userID, issuedBefore.Truncate(time.Second)); err != nil {
+		return err
+	}
+
+	// If everything is ok, no error will be returned
+	return nil
+}
+
+// IsRevoked checks if a token issued to an user was revoked
+func (repository RevokedTokens) IsRevoked(ctx context.Context, tokenID string, userID uint64, issuedAt time.Time) (bool, error) {
+	// Executing the select statement
+	// The token is revoked by its ID or if it was issued before all user tokens were revoked
+	rows, err := repository.db.QueryContext(ctx,
+		`select 1 from revoked_tokens where token_id = ?
+		union all
+		select 1 from revoked_users where user_id = ? and revoked_before >= ?`,
+		tokenID, userID, issuedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	// If any row is returned, the token was revoked
+	return rows.Next(), rows.Err()
+}
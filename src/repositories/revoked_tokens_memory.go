@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRevokedTokens represents a revoked tokens repository, stored on the application memory
+// It's useful for single instance deployments and local development
+type MemoryRevokedTokens struct {
+	mutex   sync.RWMutex
+	tokens  map[string]time.Time
+	users   map[uint64]time.Time
+	cleaned time.Time
+}
+
+// NewMemoryRevokedTokensRepository instantiates/initializes an in-memory revoked tokens repository
+func NewMemoryRevokedTokensRepository() *MemoryRevokedTokens {
+	return &MemoryRevokedTokens{
+		tokens: make(map[string]time.Time),
+		users:  make(map[uint64]time.Time),
+	}
+}
+
+// Revoke invalidates a single token (by its ID) until it expires
+func (repository *MemoryRevokedTokens) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	// Removing tokens which already expired (at most once a minute), since they're not accepted anymore
+	now := time.Now()
+	if now.Sub(repository.cleaned) > time.Minute {
+		for ID, tokenExpiresAt := range repository.tokens {
+			if tokenExpiresAt.Before(now) {
+				delete(repository.tokens, ID)
+			}
+		}
+		repository.cleaned = now
+	}
+
+	// Revoking the token
+	repository.tokens[tokenID] = expiresAt
+	return nil
+}
+
+// RevokeAllByUser invalidates all tokens issued to an user before the provided time
+func (repository *MemoryRevokedTokens) RevokeAllByUser(ctx context.Context, userID uint64, issuedBefore time.Time) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	// Tokens issue time has seconds precision, so the time is truncated as well
+	repository.users[userID] = issuedBefore.Truncate(time.Second)
+	return nil
+}
+
+// IsRevoked checks if a token issued to an user was revoked
+func (repository *MemoryRevokedTokens) IsRevoked(ctx context.Context, tokenID string, userID uint64, issuedAt time.Time) (bool, error) {
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	// Checking if the token itself was revoked
+	if _, revoked := repository.tokens[tokenID]; revoked {
+		return true, nil
+	}
+
+	// Checking if all user tokens were revoked after the token was issued
+	revokedBefore, revoked := repository.users[userID]
+	return revoked && !issuedAt.After(revokedBefore), nil
+}
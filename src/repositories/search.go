@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"api/src/models"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// snippetWords defines how many words of the post content are shown on the search snippets
+const snippetWords = 24
+
+// searchWord is a word of a searched text, along with its position on the text
+type searchWord struct {
+	text       string
+	start, end int
+}
+
+// splitSearchWords splits a text into its words, like models.SearchWords, keeping their positions
+func splitSearchWords(text string) []searchWord {
+	var words []searchWord
+	start := -1
+	for i, char := range text + " " {
+		isWordChar := unicode.IsLetter(char) || unicode.IsDigit(char)
+		if isWordChar && start < 0 {
+			start = i
+		} else if !isWordChar && start >= 0 {
+			words = append(words, searchWord{strings.ToLower(text[start:i]), start, i})
+			start = -1
+		}
+	}
+	return words
+}
+
+// matchesAt checks if a search term matches the words starting at a specific index
+func matchesAt(term models.SearchTerm, words []searchWord, index int) bool {
+	if index+len(term.Words) > len(words) {
+		return false
+	}
+	for i, word := range term.Words {
+		text := words[index+i].text
+		if term.Prefix && !strings.HasPrefix(text, word) || !term.Prefix && text != word {
+			return false
+		}
+	}
+	return true
+}
+
+// searchSnippet returns the part of the content around the first matching word, with the words which match the
+// terms inside <mark> tags (the rest of the text is HTML escaped). It starts from the beginning of the content
+// when no words match (e.g. when only the title matches)
+func searchSnippet(content string, terms []models.SearchTerm) string {
+	words := splitSearchWords(content)
+	if len(words) == 0 {
+		return html.EscapeString(content)
+	}
+
+	// Finding the matching words
+	marked := make([]bool, len(words))
+	first := -1
+	for i := range words {
+		for _, term := range terms {
+			if !matchesAt(term, words, i) {
+				continue
+			}
+			for j := range term.Words {
+				marked[i+j] = true
+			}
+			if first < 0 {
+				first = i
+			}
+		}
+	}
+
+	// Showing a few words before the first match
+	start := 0
+	if first > snippetWords/4 {
+		start = first - snippetWords/4
+	}
+	end := start + snippetWords
+	if end > len(words) {
+		end = len(words)
+	}
+
+	// Writing the snippet, from the start of its first word (or the content) to the end of its last word (or the content)
+	var snippet strings.Builder
+	position := words[start].start
+	if start == 0 {
+		position = 0
+	} else {
+		snippet.WriteString("…")
+	}
+	for i := start; i < end; i++ {
+		if !marked[i] {
+			continue
+		}
+		snippet.WriteString(html.EscapeString(content[position:words[i].start]))
+		snippet.WriteString("<mark>" + html.EscapeString(content[words[i].start:words[i].end]) + "</mark>")
+		position = words[i].end
+	}
+	if end == len(words) {
+		snippet.WriteString(html.EscapeString(content[position:]))
+	} else {
+		snippet.WriteString(html.EscapeString(content[position:words[end-1].end]) + "…")
+	}
+	return snippet.String()
+}
@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"api/src/database"
+	"api/src/models"
+	"context"
+	"time"
+)
+
+// Sessions represents a sessions repository
+type Sessions struct {
+	db database.Executor
+}
+
+// NewSessionsRepository instantiates/initializes a sessions repository
+func NewSessionsRepository(db database.Executor) *Sessions {
+	return &Sessions{db}
+}
+
+// Create is a Sessions' method to store new sessions on the repository
+func (repository Sessions) Create(ctx context.Context, session models.Session) error {
+	// Preparing the insert statment
+	statement, err := repository.db.PrepareContext(ctx,
+		"insert into sessions (id, user_id, user_agent, ip_address, last_used_at) values (?, ?, ?, ?, ?)",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the query to store the session
+	if _, err = statement.ExecContext(ctx, session.ID, session.UserID, session.UserAgent, session.IPAddress, time.Now()); err != nil {
+		return err
+	}
+
+	// Returning the function
+	return nil
+}
+
+// SearchByUser searchs all active sessions from a specific user, used since the provided time
+func (repository Sessions) SearchByUser(ctx context.Context, userID uint64, usedSince time.Time) ([]models.Session, error) {
+	// Executing the select statement (most recently used sessions first)
+	rows, err := repository.db.QueryContext(ctx,
+		`select id, user_id, user_agent, ip_address, createdAt, last_used_at
+		from sessions where user_id = ? and revoked_at is null and last_used_at >= ?
+		order by last_used_at desc`,
+		userID, usedSince,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// Reading rows data
+	var sessions []models.Session
+	for rows.Next() {
+		var session models.Session
+		if err = rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	// Returning the sessions
+	return sessions, nil
+}
+
+// SearchByID searchs a specific session by its ID
+func (repository Sessions) SearchByID(ctx context.Context, ID string) (models.Session, error) {
+	// Executing the select statement
+	rows, err := repository.db.QueryContext(ctx,
+		`select id, user_id, user_agent, ip_address, createdAt, last_used_at, revoked_at
+		from sessions where id = ?`,
+		ID,
+	)
+	if err != nil {
+		// We return an empty session if an error occurs
+		return models.Session{}, err
+	}
+	defer rows.Close()
+
+	// Reading row data
+	var session models.Session
+	if rows.Next() {
+		// Getting session
+		if err = rows.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.RevokedAt,
+		); err != nil {
+			// We return an empty session if an error occurs
+			return models.Session{}, err
+		}
+	}
+
+	// Returning the session data
+	return session, nil
+}
+
+// Touch updates a specific session last used time
+// The session is only updated if it wasn't used since the provided time, to avoid a write on every request
+func (repository Sessions) Touch(ctx context.Context, ID string, notUsedSince time.Time) error {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update sessions set last_used_at = ? where id = ? and last_used_at < ?",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	if _, err = statement.ExecContext(ctx, time.Now(), ID, notUsedSince); err != nil {
+		return err
+	}
+
+	// Returning the function
+	return nil
+}
+
+// Revoke ends a specific session from an user
+// It returns false if the session doesn't exist, belongs to another user or was already revoked
+func (repository Sessions) Revoke(ctx context.Context, userID uint64, ID string) (bool, error) {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update sessions set revoked_at = ? where id = ? and user_id = ? and revoked_at is null",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	result, err := statement.ExecContext(ctx, time.Now(), ID, userID)
+	if err != nil {
+		return false, err
+	}
+
+	// Checking if the session was actually revoked by this call
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	// Returning the function
+	return rowsAffected == 1, nil
+}
+
+// RevokeByUser ends all active sessions from a specific user
+func (repository Sessions) RevokeByUser(ctx context.Context, userID uint64) error {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update sessions set revoked_at = ? where user_id = ? and revoked_at is null",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	if _, err = statement.ExecContext(ctx, time.Now(), userID); err != nil {
+		return err
+	}
+
+	// Returning the function
+	return nil
+}
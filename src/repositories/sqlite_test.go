@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"api/src/config"
+	"api/src/database"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openSQLiteDatabase opens an empty in-memory SQLite database, with the connection settings used by the application,
+// and applies the migrations to it. Each test has its own database, shared by the connections of its pool
+func openSQLiteDatabase(t *testing.T) *database.DB {
+	t.Helper()
+
+	config.DbDriver = "sqlite"
+	config.DbConnString = fmt.Sprintf(
+		"file:/%s.db?vfs=memdb&%s", strings.ReplaceAll(t.Name(), "/", "_"), config.SQLiteConnParams,
+	)
+	db, err := database.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err = db.MigrateUp(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	db := openSQLiteDatabase(t)
+	migrations, err := database.Migrations(db.Dialect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSchemaVersion(t, db, migrations[len(migrations)-1].Version)
+
+	// Reverting all migrations removes all tables (but the migrations one, and the SQLite internal ones)
+	reverted, err := db.MigrateDown(len(migrations), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reverted) != len(migrations) {
+		t.Fatalf("got %d migrations reverted, want %d", len(reverted), len(migrations))
+	}
+	checkSchemaVersion(t, db, 0)
+	var tables int
+	if err = db.QueryRowContext(context.Background(),
+		"select count(*) from sqlite_master where type = 'table' and name <> 'schema_migrations' and name not like 'sqlite_%'",
+	).Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Fatalf("got %d tables after reverting all migrations, want none", tables)
+	}
+
+	// Applying them again
+	applied, err := db.MigrateUp(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(migrations) {
+		t.Fatalf("got %d migrations applied, want %d", len(applied), len(migrations))
+	}
+	checkSchemaVersion(t, db, migrations[len(migrations)-1].Version)
+}
+
+func TestSQLiteMigrationsKeepLegacyLikes(t *testing.T) {
+	db := openSQLiteDatabase(t)
+	ctx := context.Background()
+
+	// Going back to the schema before the likes were recorded by user, with a post liked 3 times
+	if _, err := db.MigrateDown(countAfter(t, db, "post_likes"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, "insert into users (name, username, email, pass) values ('a', 'a', 'a@devbook.local', 'hash')"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ExecContext(ctx, "insert into posts (title, content, author_id, likes) values ('title', 'content', 1, 3)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The likes given before are kept as legacy likes, and the new ones are added to them
+	if _, err := db.MigrateUp(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	store := NewDatabaseStore(db)
+	checkLikes(t, store, 1, 1, 3, false)
+	likePost(t, store, 1, 1, true)
+	checkLikes(t, store, 1, 1, 4, true)
+}
+
+// countAfter returns how many migrations come after the one with the provided name (including it)
+func countAfter(t *testing.T, db *database.DB, name string) int {
+	t.Helper()
+	migrations, err := database.Migrations(db.Dialect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, migration := range migrations {
+		if migration.Name == name {
+			return len(migrations) - i
+		}
+	}
+	t.Fatalf("migration %s not found", name)
+	return 0
+}
+
+// checkSchemaVersion checks the version of the last migration applied to the database
+func checkSchemaVersion(t *testing.T, db *database.DB, want uint64) {
+	t.Helper()
+	version, err := db.SchemaVersion()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != want {
+		t.Fatalf("got schema version %d, want %d", version, want)
+	}
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"time"
+)
+
+// UserStore represents the operations on the users data, regardless of where it's stored
+type UserStore interface {
+	Create(ctx context.Context, user models.User) (uint64, error)
+	Search(ctx context.Context, nameOrUsername string, page models.PageRequest) ([]models.User, bool, error)
+	SearchByID(ctx context.Context, ID uint64) (models.User, error)
+	Update(ctx context.Context, ID uint64, user models.User) error
+	Delete(ctx context.Context, ID uint64) error
+	Restore(ctx context.Context, ID uint64) (bool, error)
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	SearchByEmail(ctx context.Context, email string) (models.User, error)
+	SearchByUsername(ctx context.Context, username string) (models.User, error)
+	SearchDeletedByEmail(ctx context.Context, email string) (models.User, error)
+	SearchDeletedByUsername(ctx context.Context, username string) (models.User, error)
+	Follow(ctx context.Context, userID, followerID uint64) error
+	Unfollow(ctx context.Context, userID, followerID uint64) error
+	SearchFollowers(ctx context.Context, userID uint64, page models.PageRequest) ([]models.User, bool, error)
+	SearchFollowing(ctx context.Context, userID uint64, page models.PageRequest) ([]models.User, bool, error)
+	SearchLikers(ctx context.Context, postID uint64, page models.PageRequest) ([]models.User, bool, error)
+	SearchPassword(ctx context.Context, userID uint64) (string, error)
+	ChangePassword(ctx context.Context, userID uint64, hashPass string) error
+	ReplacePassword(ctx context.Context, userID uint64, currentHashPass, newHashPass string) (bool, error)
+	SearchTokenData(ctx context.Context, ID uint64) (models.User, error)
+	IncrementTokenVersion(ctx context.Context, ID uint64) error
+	ChangeRole(ctx context.Context, ID uint64, role string) error
+	SearchTwoFactor(ctx context.Context, ID uint64) (models.TwoFactor, error)
+	SetTwoFactorSecret(ctx context.Context, ID uint64, secret string) (bool, error)
+	EnableTwoFactor(ctx context.Context, ID uint64, lastStep int64) error
+	DisableTwoFactor(ctx context.Context, ID uint64) error
+	UseTwoFactorStep(ctx context.Context, ID uint64, step int64) (bool, error)
+	VerifyEmail(ctx context.Context, ID uint64, email string) (bool, error)
+}
+
+// PostStore represents the operations on the posts data, regardless of where it's stored
+type PostStore interface {
+	Create(ctx context.Context, post models.Post) (uint64, error)
+	Search(ctx context.Context, userID uint64, page models.PageRequest) ([]models.Post, bool, error)
+	SearchByID(ctx context.Context, postID, viewerID uint64) (models.Post, error)
+	SearchByIDForUpdate(ctx context.Context, postID uint64) (models.Post, error)
+	SearchDeletedByIDForUpdate(ctx context.Context, postID uint64) (models.Post, error)
+	Update(ctx context.Context, ID uint64, post models.Post) error
+	Delete(ctx context.Context, ID uint64) error
+	Restore(ctx context.Context, ID uint64) (bool, error)
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	SearchByUser(ctx context.Context, userID, viewerID uint64, page models.PageRequest) ([]models.Post, bool, error)
+	Like(ctx context.Context, postID, userID uint64) (bool, error)
+	Dislike(ctx context.Context, postID, userID uint64) (bool, error)
+}
+
+// Store represents where the users and posts data is kept: it provides their repositories, and runs the units of work
+// (multi-step operations whose changes are either all committed or all rolled back) on the same data
+type Store interface {
+	Users() UserStore
+	Posts() PostStore
+	RunUnitOfWork(ctx context.Context, work func(unit UnitOfWork) error) error
+}
+
+// PostSearcher represents the full-text search over the posts, regardless of the search engine
+type PostSearcher interface {
+	Search(ctx context.Context, search models.PostSearch, viewerID uint64) ([]models.PostSearchResult, error)
+}
+
+// Checking the implementations satisfy the interfaces
+var (
+	_ UserStore    = (*Users)(nil)
+	_ UserStore    = (*MemoryUsers)(nil)
+	_ PostStore    = (*Posts)(nil)
+	_ PostStore    = (*MemoryPosts)(nil)
+	_ PostSearcher = (*PostsSearch)(nil)
+	_ PostSearcher = (*MemoryPostsSearch)(nil)
+	_ Store        = (*DatabaseStore)(nil)
+	_ Store        = (*MemoryStore)(nil)
+)
@@ -0,0 +1,484 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// storeFactories create the stores which the tests run against, each one with its own empty data
+var storeFactories = []struct {
+	name     string
+	newStore func(t *testing.T) Store
+}{
+	{"sqlite", func(t *testing.T) Store { return NewDatabaseStore(openSQLiteDatabase(t)) }},
+	{"memory", func(t *testing.T) Store { return NewMemoryStore(NewMemoryDatabase()) }},
+}
+
+// forEachStore runs a test against each store
+func forEachStore(t *testing.T, test func(t *testing.T, store Store)) {
+	for _, factory := range storeFactories {
+		t.Run(factory.name, func(t *testing.T) {
+			test(t, factory.newStore(t))
+		})
+	}
+}
+
+func TestFollowIsIdempotent(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		userID := createTestUser(t, store, "followed")
+		followerID := createTestUser(t, store, "follower")
+
+		// Following twice keeps a single follow
+		for i := 0; i < 2; i++ {
+			if err := store.Users().Follow(ctx, userID, followerID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		checkUsers(t, "followers", searchFollowers(t, store, userID), followerID)
+		following, _, err := store.Users().SearchFollowing(ctx, followerID, models.PageRequest{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkUsers(t, "following", following, userID)
+
+		// Unfollowing twice is also allowed
+		for i := 0; i < 2; i++ {
+			if err := store.Users().Unfollow(ctx, userID, followerID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		checkUsers(t, "followers", searchFollowers(t, store, userID))
+
+		// The follower must exist
+		if err := store.Users().Follow(ctx, userID, followerID+100); err == nil {
+			t.Fatal("got no error following by a missing user")
+		}
+
+		// Deleted users can't be followed
+		if err := store.Users().Delete(ctx, userID); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Users().Follow(ctx, userID, followerID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.Users().Restore(ctx, userID); err != nil {
+			t.Fatal(err)
+		}
+		checkUsers(t, "followers", searchFollowers(t, store, userID))
+	})
+}
+
+func TestLikeAndDislikeCounts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		authorID := createTestUser(t, store, "author")
+		otherID := createTestUser(t, store, "other")
+		postID := createTestPost(t, store, authorID, "title", "content")
+
+		// Liking twice counts a single like
+		likePost(t, store, postID, authorID, true)
+		likePost(t, store, postID, authorID, false)
+		likePost(t, store, postID, otherID, true)
+		checkLikes(t, store, postID, authorID, 2, true)
+
+		// Disliking twice removes a single like
+		dislikePost(t, store, postID, authorID, true)
+		dislikePost(t, store, postID, authorID, false)
+		checkLikes(t, store, postID, authorID, 1, false)
+		checkLikes(t, store, postID, otherID, 1, true)
+		likers, _, err := store.Users().SearchLikers(context.Background(), postID, models.PageRequest{Limit: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkUsers(t, "likers", likers, otherID)
+
+		// Deleted posts can't be liked
+		if err = store.Posts().Delete(context.Background(), postID); err != nil {
+			t.Fatal(err)
+		}
+		likePost(t, store, postID, authorID, false)
+	})
+}
+
+func TestConcurrentLikes(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		authorID := createTestUser(t, store, "author")
+		postID := createTestPost(t, store, authorID, "title", "content")
+		var userIDs []uint64
+		for i := 0; i < 8; i++ {
+			userIDs = append(userIDs, createTestUser(t, store, fmt.Sprintf("user%d", i)))
+		}
+
+		// Each like is counted, even when they're given at the same time
+		var wait sync.WaitGroup
+		errs := make(chan error, len(userIDs))
+		for _, userID := range userIDs {
+			wait.Add(1)
+			go func(userID uint64) {
+				defer wait.Done()
+				errs <- store.RunUnitOfWork(context.Background(), func(unit UnitOfWork) error {
+					_, err := unit.Posts.Like(context.Background(), postID, userID)
+					return err
+				})
+			}(userID)
+		}
+		wait.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		checkLikes(t, store, postID, userIDs[0], uint64(len(userIDs)), true)
+	})
+}
+
+func TestSoftDeleteRestoreAndPurge(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		authorID := createTestUser(t, store, "author")
+		likerID := createTestUser(t, store, "liker")
+		postID := createTestPost(t, store, authorID, "title", "content")
+		likePost(t, store, postID, likerID, true)
+		if err := store.Users().Follow(ctx, authorID, likerID); err != nil {
+			t.Fatal(err)
+		}
+
+		// Deleted users (and their posts) are hidden until they're restored
+		if err := store.Users().Delete(ctx, authorID); err != nil {
+			t.Fatal(err)
+		}
+		if user, err := store.Users().SearchByID(ctx, authorID); err != nil || user.ID != 0 {
+			t.Fatalf("got user %d (error: %v) after deleting it, want none", user.ID, err)
+		}
+		if post, err := store.Posts().SearchByID(ctx, postID, likerID); err != nil || post.ID != 0 {
+			t.Fatalf("got post %d (error: %v) after deleting its author, want none", post.ID, err)
+		}
+		if user, err := store.Users().SearchDeletedByEmail(ctx, "author@devbook.local"); err != nil || user.ID != authorID {
+			t.Fatalf("got deleted user %d (error: %v), want %d", user.ID, err, authorID)
+		}
+		for _, want := range []bool{true, false} {
+			if restored, err := store.Users().Restore(ctx, authorID); err != nil || restored != want {
+				t.Fatalf("got restored %v (error: %v), want %v", restored, err, want)
+			}
+		}
+		checkLikes(t, store, postID, likerID, 1, true)
+
+		// Deleted posts can be found to be restored
+		if err := store.Posts().Delete(ctx, postID); err != nil {
+			t.Fatal(err)
+		}
+		if post, err := store.Posts().SearchDeletedByIDForUpdate(ctx, postID); err != nil || post.ID != postID {
+			t.Fatalf("got deleted post %d (error: %v), want %d", post.ID, err, postID)
+		}
+		if restored, err := store.Posts().Restore(ctx, postID); err != nil || !restored {
+			t.Fatalf("got restored %v (error: %v), want true", restored, err)
+		}
+
+		// Purging a deleted user removes its follows and likes
+		if err := store.Users().Delete(ctx, likerID); err != nil {
+			t.Fatal(err)
+		}
+		if purged, err := store.Users().Purge(ctx, time.Now().Add(-time.Hour)); err != nil || purged != 0 {
+			t.Fatalf("got %d users purged (error: %v) before the retention period, want none", purged, err)
+		}
+		if purged, err := store.Users().Purge(ctx, time.Now().Add(time.Hour)); err != nil || purged != 1 {
+			t.Fatalf("got %d users purged (error: %v), want 1", purged, err)
+		}
+		checkLikes(t, store, postID, authorID, 0, false)
+		checkUsers(t, "followers", searchFollowers(t, store, authorID))
+
+		// Purging a deleted post removes it for good
+		if err := store.Posts().Delete(ctx, postID); err != nil {
+			t.Fatal(err)
+		}
+		if purged, err := store.Posts().Purge(ctx, time.Now().Add(time.Hour)); err != nil || purged != 1 {
+			t.Fatalf("got %d posts purged (error: %v), want 1", purged, err)
+		}
+		if post, err := store.Posts().SearchDeletedByIDForUpdate(ctx, postID); err != nil || post.ID != 0 {
+			t.Fatalf("got deleted post %d (error: %v) after purging it, want none", post.ID, err)
+		}
+	})
+}
+
+func TestKeysetPagination(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		authorID := createTestUser(t, store, "author")
+		var postIDs []uint64
+		for i := 0; i < 5; i++ {
+			postIDs = append(postIDs, createTestPost(t, store, authorID, "title", "content"))
+		}
+
+		// Posts are listed newest first
+		tests := []struct {
+			name  string
+			page  models.PageRequest
+			posts []uint64
+			more  bool
+		}{
+			{"first page", models.PageRequest{Limit: 2}, []uint64{postIDs[4], postIDs[3]}, true},
+			{"next page", models.PageRequest{Limit: 2, After: postIDs[3]}, []uint64{postIDs[2], postIDs[1]}, true},
+			{"last page", models.PageRequest{Limit: 2, After: postIDs[1]}, []uint64{postIDs[0]}, false},
+			{"previous page", models.PageRequest{Limit: 2, Before: postIDs[1]}, []uint64{postIDs[3], postIDs[2]}, true},
+			{"first page, backwards", models.PageRequest{Limit: 2, Before: postIDs[3]}, []uint64{postIDs[4]}, false},
+		}
+		for _, test := range tests {
+			posts, more, err := store.Posts().SearchByUser(ctx, authorID, authorID, test.page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var IDs []uint64
+			for _, post := range posts {
+				IDs = append(IDs, post.ID)
+			}
+			if !equalIDs(IDs, test.posts) || more != test.more {
+				t.Errorf("%s: got posts %v (more: %v), want %v (more: %v)", test.name, IDs, more, test.posts, test.more)
+			}
+		}
+
+		// Users are listed by their IDs
+		otherID := createTestUser(t, store, "other")
+		users, more, err := store.Users().Search(ctx, "", models.PageRequest{Limit: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkUsers(t, "first users page", users, authorID)
+		users, moreAfter, err := store.Users().Search(ctx, "", models.PageRequest{Limit: 1, After: authorID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkUsers(t, "next users page", users, otherID)
+		if !more || moreAfter {
+			t.Errorf("got more users %v and %v, want true and false", more, moreAfter)
+		}
+	})
+}
+
+func TestUnitOfWorkRollback(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		ctx := context.Background()
+		authorID := createTestUser(t, store, "author")
+		postID := createTestPost(t, store, authorID, "title", "content")
+
+		// The changes of a failed unit of work are all rolled back
+		failure := errors.New("failure")
+		err := store.RunUnitOfWork(ctx, func(unit UnitOfWork) error {
+			if _, err := unit.Posts.Like(ctx, postID, authorID); err != nil {
+				return err
+			}
+			if err := unit.Posts.Update(ctx, postID, models.Post{Title: "changed", Content: "changed"}); err != nil {
+				return err
+			}
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("got error %v, want %v", err, failure)
+		}
+		post := checkLikes(t, store, postID, authorID, 0, false)
+		if post.Title != "title" {
+			t.Fatalf("got title %q after the rollback, want %q", post.Title, "title")
+		}
+	})
+}
+
+func TestPostsSearch(t *testing.T) {
+	forEachStore(t, func(t *testing.T, store Store) {
+		authorID := createTestUser(t, store, "author")
+		otherID := createTestUser(t, store, "other")
+		goID := createTestPost(t, store, authorID, "Learning Go", "Goroutines and channels")
+		recipeID := createTestPost(t, store, authorID, "Cooking", "Spicy noodles & <rice> recipe")
+		otherRecipeID := createTestPost(t, store, otherID, "Dinner", "Noodles recipe, not spicy")
+		deletedID := createTestPost(t, store, authorID, "Deleted", "Spicy noodles recipe")
+		if err := store.Posts().Delete(context.Background(), deletedID); err != nil {
+			t.Fatal(err)
+		}
+
+		tests := []struct {
+			name     string
+			search   models.PostSearch
+			terms    []models.SearchTerm
+			results  []uint64
+			snippets []string
+		}{
+			{
+				name:   "phrase and prefix, escaping the snippet",
+				search: models.PostSearch{Query: `"spicy noodles" rec*`},
+				terms: []models.SearchTerm{
+					{Words: []string{"spicy", "noodles"}},
+					{Words: []string{"rec"}, Prefix: true},
+				},
+				results:  []uint64{recipeID},
+				snippets: []string{"<mark>Spicy</mark> <mark>noodles</mark> &amp; &lt;rice&gt; <mark>recipe</mark>"},
+			},
+			{
+				name:    "phrase words out of order",
+				search:  models.PostSearch{Query: `"noodles spicy"`},
+				results: nil,
+			},
+			{
+				name:    "word without prefix",
+				search:  models.PostSearch{Query: "spicy rec"},
+				results: nil,
+			},
+			{
+				name:     "words on any order, most relevant first",
+				search:   models.PostSearch{Query: "recipe noodles"},
+				results:  []uint64{otherRecipeID, recipeID},
+				snippets: []string{"<mark>Noodles</mark> <mark>recipe</mark>, not spicy", "Spicy <mark>noodles</mark> &amp; &lt;rice&gt; <mark>recipe</mark>"},
+			},
+			{
+				name:    "filtered by author",
+				search:  models.PostSearch{Query: "recipe", AuthorID: otherID},
+				results: []uint64{otherRecipeID},
+			},
+			{
+				name:     "matching only the title",
+				search:   models.PostSearch{Query: "learn*"},
+				results:  []uint64{goID},
+				snippets: []string{"Goroutines and channels"},
+			},
+		}
+		for _, test := range tests {
+			test.search.Limit = 10
+			if err := test.search.Prepare(); err != nil {
+				t.Fatal(err)
+			}
+			if test.terms != nil && !reflect.DeepEqual(test.search.Terms, test.terms) {
+				t.Errorf("%s: got terms %v, want %v", test.name, test.search.Terms, test.terms)
+			}
+			results, err := searcherFor(store).Search(context.Background(), test.search, authorID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var IDs []uint64
+			var snippets []string
+			for _, result := range results {
+				IDs = append(IDs, result.ID)
+				snippets = append(snippets, result.Snippet)
+			}
+			if !equalIDs(IDs, test.results) {
+				t.Errorf("%s: got results %v, want %v", test.name, IDs, test.results)
+			} else if test.snippets != nil && !reflect.DeepEqual(snippets, test.snippets) {
+				t.Errorf("%s: got snippets %q, want %q", test.name, snippets, test.snippets)
+			}
+		}
+	})
+}
+
+// searcherFor returns the posts search over the store data
+func searcherFor(store Store) PostSearcher {
+	if memoryStore, ok := store.(*MemoryStore); ok {
+		return NewMemoryPostsSearchRepository(memoryStore.db)
+	}
+	return NewPostsSearchRepository(store.(*DatabaseStore).db)
+}
+
+// createTestUser creates an user whose name, username and email are based on the provided name
+func createTestUser(t *testing.T, store Store, name string) uint64 {
+	t.Helper()
+	ID, err := store.Users().Create(context.Background(), models.User{
+		Name:     name,
+		Username: name,
+		Email:    name + "@devbook.local",
+		Pass:     "hash",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ID
+}
+
+// createTestPost creates a post from a specific author
+func createTestPost(t *testing.T, store Store, authorID uint64, title, content string) uint64 {
+	t.Helper()
+	ID, err := store.Posts().Create(context.Background(), models.Post{Title: title, Content: content, AuthorID: authorID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ID
+}
+
+// likePost likes a post in a unit of work (as the handlers do), checking whether the like was recorded
+func likePost(t *testing.T, store Store, postID, userID uint64, want bool) {
+	t.Helper()
+	var liked bool
+	if err := store.RunUnitOfWork(context.Background(), func(unit UnitOfWork) (err error) {
+		liked, err = unit.Posts.Like(context.Background(), postID, userID)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if liked != want {
+		t.Fatalf("user %d liking post %d: got %v, want %v", userID, postID, liked, want)
+	}
+}
+
+// dislikePost removes a like from a post in a unit of work (as the handlers do), checking whether it was removed
+func dislikePost(t *testing.T, store Store, postID, userID uint64, want bool) {
+	t.Helper()
+	var disliked bool
+	if err := store.RunUnitOfWork(context.Background(), func(unit UnitOfWork) (err error) {
+		disliked, err = unit.Posts.Dislike(context.Background(), postID, userID)
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if disliked != want {
+		t.Fatalf("user %d disliking post %d: got %v, want %v", userID, postID, disliked, want)
+	}
+}
+
+// checkLikes checks a post likes counter, and whether the viewer likes it, returning the post
+func checkLikes(t *testing.T, store Store, postID, viewerID, likes uint64, likedByMe bool) models.Post {
+	t.Helper()
+	post, err := store.Posts().SearchByID(context.Background(), postID, viewerID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.Likes != likes || post.LikedByMe != likedByMe {
+		t.Fatalf("got %d likes (liked by the viewer: %v), want %d (%v)", post.Likes, post.LikedByMe, likes, likedByMe)
+	}
+	return post
+}
+
+// searchFollowers returns the first page of an user followers
+func searchFollowers(t *testing.T, store Store, userID uint64) []models.User {
+	t.Helper()
+	followers, _, err := store.Users().SearchFollowers(context.Background(), userID, models.PageRequest{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return followers
+}
+
+// checkUsers checks the IDs of a list of users
+func checkUsers(t *testing.T, list string, users []models.User, want ...uint64) {
+	t.Helper()
+	var IDs []uint64
+	for _, user := range users {
+		IDs = append(IDs, user.ID)
+	}
+	if !equalIDs(IDs, want) {
+		t.Fatalf("got %s %v, want %v", list, IDs, want)
+	}
+}
+
+// equalIDs checks if two lists have the same IDs, in the same order
+func equalIDs(IDs, want []uint64) bool {
+	if len(IDs) != len(want) {
+		return false
+	}
+	for i := range IDs {
+		if IDs[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
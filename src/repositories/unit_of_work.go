@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"api/src/database"
+	"context"
+)
+
+// UnitOfWork holds the repositories used by a multi-step operation, run by a Store (see Store.RunUnitOfWork)
+// Their changes are either all committed or all rolled back (on the database, they share the same transaction)
+type UnitOfWork struct {
+	Users                UserStore
+	Posts                PostStore
+	RefreshTokens        *RefreshTokens
+	PersonalAccessTokens *PersonalAccessTokens
+	Sessions             *Sessions
+	RecoveryCodes        *RecoveryCodes
+	PasswordResetTokens  *PasswordResetTokens
+}
+
+// newUnitOfWork instantiates/initializes the repositories of a unit of work, on a specific executor
+func newUnitOfWork(executor database.Executor) UnitOfWork {
+	return UnitOfWork{
+		Users:                NewUsersRepository(executor),
+		Posts:                NewPostsRepository(executor),
+		RefreshTokens:        NewRefreshTokensRepository(executor),
+		PersonalAccessTokens: NewPersonalAccessTokensRepository(executor),
+		Sessions:             NewSessionsRepository(executor),
+		RecoveryCodes:        NewRecoveryCodesRepository(executor),
+		PasswordResetTokens:  NewPasswordResetTokensRepository(executor),
+	}
+}
+
+// DatabaseStore is the Store which keeps the data on the database
+type DatabaseStore struct {
+	db    *database.DB
+	users *Users
+	posts *Posts
+}
+
+// NewDatabaseStore instantiates/initializes a store on the database connection pool
+func NewDatabaseStore(db *database.DB) *DatabaseStore {
+	return &DatabaseStore{db, NewUsersRepository(db), NewPostsRepository(db)}
+}
+
+// Users returns the users repository, outside of any unit of work
+func (store *DatabaseStore) Users() UserStore {
+	return store.users
+}
+
+// Posts returns the posts repository, outside of any unit of work
+func (store *DatabaseStore) Posts() PostStore {
+	return store.posts
+}
+
+// RunUnitOfWork runs a function with repositories sharing a new transaction
+// The transaction is committed if the function succeeds, and rolled back if it returns an error (or panics)
+func (store *DatabaseStore) RunUnitOfWork(ctx context.Context, work func(unit UnitOfWork) error) error {
+	return store.db.Transaction(ctx, func(tx *database.Tx) error {
+		return work(newUnitOfWork(tx))
+	})
+}
@@ -1,25 +1,27 @@
 package repositories
 
 import (
+	"api/src/database"
 	"api/src/models"
-	"database/sql"
+	"context"
 	"fmt"
+	"time"
 )
 
 // Users represents an users repository
 type Users struct {
-	db *sql.DB
+	db database.Executor
 }
 
 // NewUsersRepository instantiates/initializes a users repository
-func NewUsersRepository(db *sql.DB) *Users {
+func NewUsersRepository(db database.Executor) *Users {
 	return &Users{db}
 }
 
 // Create is a Users' method to create new users on the repository
-func (repository Users) Create(user models.User) (uint64, error) {
+func (repository Users) Create(ctx context.Context, user models.User) (uint64, error) {
 	// Preparing the insert statment
-	statement, err := repository.db.Prepare(
+	statement, err := repository.db.PrepareInsertContext(ctx,
 		"insert into users (name, username, email, pass) values(?, ?, ?, ?)",
 	)
 	if err != nil {
@@ -28,33 +30,31 @@ func (repository Users) Create(user models.User) (uint64, error) {
 	defer statement.Close()
 
 	// Executing the query to create new user
-	result, err := statement.Exec(user.Name, user.Username, user.Email, user.Pass)
-	if err != nil {
-		return 0, err
-	}
-
-	// Getting the last inserted user ID
-	lastInsertedId, err := result.LastInsertId()
+	ID, err := statement.InsertContext(ctx, user.Name, user.Username, user.Email, user.Pass)
 	if err != nil {
 		return 0, err
 	}
 
 	// Finally, we return the inserted user ID
-	return uint64(lastInsertedId), nil
+	return ID, nil
 }
 
-// Search all users with specified name or username
-func (repository Users) Search(nameOrUsername string) ([]models.User, error) {
+// Search a page of the users with specified name or username (ordered by their IDs)
+// It also returns whether there are more users beyond the page
+func (repository Users) Search(ctx context.Context, nameOrUsername string, page models.PageRequest) ([]models.User, bool, error) {
 	// Formatting the query parameter
 	nameOrUsername = fmt.Sprintf("%%%s%%", nameOrUsername) // -> %nameOrUsername%
 
 	// Executing the select statement (we won't return the users passwords)
-	rows, err := repository.db.Query(
-		"select id, name, username, email, createdAt from users where name LIKE ? or username LIKE ?",
-		nameOrUsername, nameOrUsername,
+	// Names are compared in lower case, since LIKE is case sensitive on some databases
+	condition, order, pageArgs := keyset("id", false, page)
+	rows, err := repository.db.QueryContext(ctx,
+		`select id, name, username, email, createdAt from users
+		where (lower(name) like lower(?) or lower(username) like lower(?)) and deleted_at is null`+condition+order,
+		append([]interface{}{nameOrUsername, nameOrUsername}, pageArgs...)...,
 	)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 	defer rows.Close()
 
@@ -70,21 +70,22 @@ func (repository Users) Search(nameOrUsername string) ([]models.User, error) {
 			&user.Email,
 			&user.CreatedAt,
 		); err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		// Appending to the users list
 		users = append(users, user)
 	}
 
-	// Returning the users slice
-	return users, nil
+	// Returning the users page
+	users, more := pageItems(users, page)
+	return users, more, rows.Err()
 }
 
 // SearchByID a specific user by its ID
-func (repository Users) SearchByID(ID uint64) (models.User, error) {
+func (repository Users) SearchByID(ctx context.Context, ID uint64) (models.User, error) {
 	// Executing the select statement (we won't return the users passwords)
-	rows, err := repository.db.Query(
-		"select id, name, username, email, createdAt from users where ID = ?",
+	rows, err := repository.db.QueryContext(ctx,
+		"select id, name, username, email, email_verified_at, createdAt from users where ID = ? and deleted_at is null",
 		ID,
 	)
 	if err != nil {
@@ -102,6 +103,7 @@ func (repository Users) SearchByID(ID uint64) (models.User, error) {
 			&user.Name,
 			&user.Username,
 			&user.Email,
+			&user.EmailVerifiedAt,
 			&user.CreatedAt,
 		); err != nil {
 			// We return an empty user if an error occurs
@@ -114,10 +116,15 @@ func (repository Users) SearchByID(ID uint64) (models.User, error) {
 }
 
 // Update will edit a specific user data by its ID
-func (repository Users) Update(ID uint64, user models.User) error {
+// If the email changes, it must be verified again
+func (repository Users) Update(ctx context.Context, ID uint64, user models.User) error {
 	// Preparing the statement to execute the SQL query
-	statement, err := repository.db.Prepare(
-		"update users set name = ?, username = ?, email = ? where id = ?",
+	// The verification is checked before the email is updated, since assignments are evaluated in order
+	statement, err := repository.db.PrepareContext(ctx,
+		`update users set
+		email_verified_at = case when email = ? then email_verified_at else null end,
+		name = ?, username = ?, email = ?
+		where id = ? and deleted_at is null`,
 	)
 	if err != nil {
 		return err
@@ -125,7 +132,7 @@ func (repository Users) Update(ID uint64, user models.User) error {
 	defer statement.Close()
 
 	// Executing the update statement
-	if _, err = statement.Exec(user.Name, user.Username, user.Email, ID); err != nil {
+	if _, err = statement.ExecContext(ctx, user.Email, user.Name, user.Username, user.Email, ID); err != nil {
 		return err
 	}
 
@@ -133,17 +140,19 @@ func (repository Users) Update(ID uint64, user models.User) error {
 	return nil
 }
 
-// Delete removes a specific user from the database
-func (repository Users) Delete(ID uint64) error {
+// Delete marks a specific user as deleted, hiding it (and its posts) until it's restored or purged
+func (repository Users) Delete(ctx context.Context, ID uint64) error {
 	// Preparing the statement to execute the SQL query
-	statement, err := repository.db.Prepare("delete from users where id = ?")
+	statement, err := repository.db.PrepareContext(ctx,
+		"update users set deleted_at = ? where id = ? and deleted_at is null",
+	)
 	if err != nil {
 		return err
 	}
 	defer statement.Close()
 
 	// Executing the delete statement
-	if _, err = statement.Exec(ID); err != nil {
+	if _, err = statement.ExecContext(ctx, time.Now(), ID); err != nil {
 		return err
 	}
 
@@ -151,10 +160,98 @@ func (repository Users) Delete(ID uint64) error {
 	return nil
 }
 
+// Restore brings back a specific deleted user (along with its posts)
+// It returns false if the user doesn't exist or isn't deleted
+func (repository Users) Restore(ctx context.Context, ID uint64) (bool, error) {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update users set deleted_at = null where id = ? and deleted_at is not null",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer statement.Close()
+
+	// Executing the restore statement
+	result, err := statement.ExecContext(ctx, ID)
+	if err != nil {
+		return false, err
+	}
+
+	// Checking if the user was actually restored
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	// Returning the function
+	return rowsAffected == 1, nil
+}
+
+// Purge permanently removes the users deleted before the provided time, along with their follows, posts and likes
+// It returns how many users were removed
+func (repository Users) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	// Updating the likes counters of the posts liked by the users, so they stop counting their likes
+	// The counters are set (not decremented), so it's safe to run again if the delete statement fails
+	if _, err := repository.db.ExecContext(ctx,
+		`update posts set likes = legacy_likes + (
+			select count(*) from post_likes l inner join users u on u.id = l.user_id
+			where l.post_id = posts.id and (u.deleted_at is null or u.deleted_at >= ?)
+		)
+		where id in (
+			select l.post_id from post_likes l inner join users u on u.id = l.user_id
+			where u.deleted_at is not null and u.deleted_at < ?
+		)`,
+		deletedBefore, deletedBefore,
+	); err != nil {
+		return 0, err
+	}
+
+	// Executing the delete statement (related rows are removed by the foreign keys)
+	result, err := repository.db.ExecContext(ctx,
+		"delete from users where deleted_at is not null and deleted_at < ?", deletedBefore,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	// Returning how many users were removed
+	return result.RowsAffected()
+}
+
 // SearchByEmail a specific user by its email, as well as its hashpass (for login purposes)
-func (repository Users) SearchByEmail(email string) (models.User, error) {
-	// Executing the select statement (we will get only ID, the email and hash password)
-	rows, err := repository.db.Query("select id, pass from users where email = ?", email)
+func (repository Users) SearchByEmail(ctx context.Context, email string) (models.User, error) {
+	return repository.searchCredentials(ctx, "email", email, false)
+}
+
+// SearchByUsername a specific user by its username, as well as its hashpass (for login purposes)
+func (repository Users) SearchByUsername(ctx context.Context, username string) (models.User, error) {
+	return repository.searchCredentials(ctx, "username", username, false)
+}
+
+// SearchDeletedByEmail a specific deleted user by its email, as well as its hashpass (for restoring purposes)
+func (repository Users) SearchDeletedByEmail(ctx context.Context, email string) (models.User, error) {
+	return repository.searchCredentials(ctx, "email", email, true)
+}
+
+// SearchDeletedByUsername a specific deleted user by its username, as well as its hashpass (for restoring purposes)
+func (repository Users) SearchDeletedByUsername(ctx context.Context, username string) (models.User, error) {
+	return repository.searchCredentials(ctx, "username", username, true)
+}
+
+// searchCredentials searchs a specific user (either active or deleted) by an unique column (email or username),
+// as well as its hashpass
+func (repository Users) searchCredentials(ctx context.Context, column, value string, deleted bool) (models.User, error) {
+	condition := "deleted_at is null"
+	if deleted {
+		condition = "deleted_at is not null"
+	}
+
+	// Executing the select statement (we will get only ID, the hash password, role, token version and 2FA status)
+	// The column is never provided by the user, so it's safe to add it to the query
+	rows, err := repository.db.QueryContext(ctx,
+		fmt.Sprintf("select id, pass, role, token_version, totp_enabled from users where %s = ? and %s", column, condition), value,
+	)
 	if err != nil {
 		// We return an empty user if an error occurs
 		return models.User{}, err
@@ -168,6 +265,9 @@ func (repository Users) SearchByEmail(email string) (models.User, error) {
 		if err = rows.Scan(
 			&user.ID,
 			&user.Pass,
+			&user.Role,
+			&user.TokenVersion,
+			&user.TwoFactorEnabled,
 		); err != nil {
 			// We return an empty user if an error occurs
 			return models.User{}, err
@@ -179,11 +279,13 @@ func (repository Users) SearchByEmail(email string) (models.User, error) {
 }
 
 // Follow allows an user to follow another one
-func (repository Users) Follow(userID, followerID uint64) error {
+func (repository Users) Follow(ctx context.Context, userID, followerID uint64) error {
 	// Preparing the insert statment
-	// We'll ignore the insertion of duplicate entries
-	statement, err := repository.db.Prepare(
-		"insert ignore into followers (user_id, follower_id) values (?, ?)",
+	// We'll ignore the insertion of duplicate entries, and deleted users can't be followed
+	statement, err := repository.db.PrepareContext(ctx,
+		repository.db.Dialect().InsertIgnore(
+			"insert into followers (user_id, follower_id) select id, ? from users where id = ? and deleted_at is null",
+		),
 	)
 	if err != nil {
 		return err
@@ -191,7 +293,7 @@ func (repository Users) Follow(userID, followerID uint64) error {
 	defer statement.Close()
 
 	// Executing the query to follow the user
-	if _, err := statement.Exec(userID, followerID); err != nil {
+	if _, err := statement.ExecContext(ctx, followerID, userID); err != nil {
 		return err
 	}
 
@@ -200,9 +302,9 @@ func (repository Users) Follow(userID, followerID uint64) error {
 }
 
 // Unfollow allows an user to stop following another one
-func (repository Users) Unfollow(userID, followerID uint64) error {
+func (repository Users) Unfollow(ctx context.Context, userID, followerID uint64) error {
 	// Preparing the delete statment
-	statement, err := repository.db.Prepare(
+	statement, err := repository.db.PrepareContext(ctx,
 		"delete from followers where user_id = ? and follower_id = ?",
 	)
 	if err != nil {
@@ -211,7 +313,7 @@ func (repository Users) Unfollow(userID, followerID uint64) error {
 	defer statement.Close()
 
 	// Executing the query to unfollow the user
-	if _, err := statement.Exec(userID, followerID); err != nil {
+	if _, err := statement.ExecContext(ctx, userID, followerID); err != nil {
 		return err
 	}
 
@@ -219,18 +321,20 @@ func (repository Users) Unfollow(userID, followerID uint64) error {
 	return nil
 }
 
-// SearchFollowers returns an user followers by its ID
-func (repository Users) SearchFollowers(userID uint64) ([]models.User, error) {
+// SearchFollowers returns a page of an user followers by its ID (ordered by their IDs)
+// It also returns whether there are more followers beyond the page
+func (repository Users) SearchFollowers(ctx context.Context, userID uint64, page models.PageRequest) ([]models.User, bool, error) {
 	// Executing the select statement
 	// Here, we're making a join between the users and followers tables
-	rows, err := repository.db.Query(`
+	condition, order, pageArgs := keyset("u.id", false, page)
+	rows, err := repository.db.QueryContext(ctx, `
 		select u.id, u.name, u.username, u.email, createdAt
 		from users u inner join followers f on u.id = f.follower_id
-		where f.user_id = ?`,
-		userID)
+		where f.user_id = ? and u.deleted_at is null`+condition+order,
+		append([]interface{}{userID}, pageArgs...)...)
 	if err != nil {
 		// We return an empty user if an error occurs
-		return nil, err
+		return nil, false, err
 	}
 	defer rows.Close()
 
@@ -246,28 +350,71 @@ func (repository Users) SearchFollowers(userID uint64) ([]models.User, error) {
 			&user.Email,
 			&user.CreatedAt,
 		); err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		// Appending to the users list
 		users = append(users, user)
 	}
 
-	// Returning the users slice
-	return users, nil
+	// Returning the users page
+	users, more := pageItems(users, page)
+	return users, more, rows.Err()
 }
 
-// SearchFollowing returns users followed by another one
-func (repository Users) SearchFollowing(userID uint64) ([]models.User, error) {
+// SearchFollowing returns a page of the users followed by another one (ordered by their IDs)
+// It also returns whether there are more users beyond the page
+func (repository Users) SearchFollowing(ctx context.Context, userID uint64, page models.PageRequest) ([]models.User, bool, error) {
 	// Executing the select statement
 	// Here, we're making a join between the users and followers tables
-	rows, err := repository.db.Query(`
+	condition, order, pageArgs := keyset("u.id", false, page)
+	rows, err := repository.db.QueryContext(ctx, `
 		select u.id, u.name, u.username, u.email, createdAt
 		from users u inner join followers f on u.id = f.user_id
-		where f.follower_id = ?`,
-		userID)
+		where f.follower_id = ? and u.deleted_at is null`+condition+order,
+		append([]interface{}{userID}, pageArgs...)...)
+	if err != nil {
+		// We return an empty user if an error occurs
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	// Reading row data
+	var users []models.User
+	for rows.Next() {
+		// Getting user
+		var user models.User
+		if err = rows.Scan(
+			&user.ID,
+			&user.Name,
+			&user.Username,
+			&user.Email,
+			&user.CreatedAt,
+		); err != nil {
+			return nil, false, err
+		}
+		// Appending to the users list
+		users = append(users, user)
+	}
+
+	// Returning the users page
+	users, more := pageItems(users, page)
+	return users, more, rows.Err()
+}
+
+// SearchLikers returns a page of the users who like a post (ordered by their IDs)
+// It also returns whether there are more users beyond the page
+func (repository Users) SearchLikers(ctx context.Context, postID uint64, page models.PageRequest) ([]models.User, bool, error) {
+	// Executing the select statement
+	// Here, we're making a join between the users and post likes tables
+	condition, order, pageArgs := keyset("u.id", false, page)
+	rows, err := repository.db.QueryContext(ctx, `
+		select u.id, u.name, u.username, u.email, u.createdAt
+		from users u inner join post_likes l on u.id = l.user_id
+		where l.post_id = ? and u.deleted_at is null`+condition+order,
+		append([]interface{}{postID}, pageArgs...)...)
 	if err != nil {
 		// We return an empty user if an error occurs
-		return nil, err
+		return nil, false, err
 	}
 	defer rows.Close()
 
@@ -283,20 +430,21 @@ func (repository Users) SearchFollowing(userID uint64) ([]models.User, error) {
 			&user.Email,
 			&user.CreatedAt,
 		); err != nil {
-			return nil, err
+			return nil, false, err
 		}
 		// Appending to the users list
 		users = append(users, user)
 	}
 
-	// Returning the users slice
-	return users, nil
+	// Returning the users page
+	users, more := pageItems(users, page)
+	return users, more, rows.Err()
 }
 
 // SearchPassword returns a specific user password by its ID
-func (repository Users) SearchPassword(userID uint64) (string, error) {
+func (repository Users) SearchPassword(ctx context.Context, userID uint64) (string, error) {
 	// Executing the select statement
-	rows, err := repository.db.Query("select pass from users where id = ?", userID)
+	rows, err := repository.db.QueryContext(ctx, "select pass from users where id = ? and deleted_at is null", userID)
 	if err != nil {
 		// We return an empty string if an error occurs
 		return "", err
@@ -318,10 +466,10 @@ func (repository Users) SearchPassword(userID uint64) (string, error) {
 }
 
 // ChangePassword updates a specific user password by its ID
-func (repository Users) ChangePassword(userID uint64, hashPass string) error {
+func (repository Users) ChangePassword(ctx context.Context, userID uint64, hashPass string) error {
 	// Preparing the statement to execute the SQL query
-	statement, err := repository.db.Prepare(
-		"update users set pass = ? where id = ?",
+	statement, err := repository.db.PrepareContext(ctx,
+		"update users set pass = ? where id = ? and deleted_at is null",
 	)
 	if err != nil {
 		return err
@@ -329,10 +477,253 @@ func (repository Users) ChangePassword(userID uint64, hashPass string) error {
 	defer statement.Close()
 
 	// Executing the change password statement
-	if _, err = statement.Exec(hashPass, userID); err != nil {
+	if _, err = statement.ExecContext(ctx, hashPass, userID); err != nil {
 		return err
 	}
 
 	// Returning the function
 	return nil
 }
+
+// ReplacePassword updates a specific user password, if it's still the provided current (hashed) password
+// It returns false if the password was changed in the meantime
+func (repository Users) ReplacePassword(ctx context.Context, userID uint64, currentHashPass, newHashPass string) (bool, error) {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update users set pass = ? where id = ? and pass = ? and deleted_at is null",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	result, err := statement.ExecContext(ctx, newHashPass, userID, currentHashPass)
+	if err != nil {
+		return false, err
+	}
+
+	// Checking if the password was actually replaced
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	// Returning the function
+	return rowsAffected == 1, nil
+}
+
+// SearchTokenData returns a specific user ID, role and token version by its ID (the data carried by tokens)
+// If the user doesn't exist (e.g. it was deleted), an empty user is returned
+func (repository Users) SearchTokenData(ctx context.Context, ID uint64) (models.User, error) {
+	// Executing the select statement
+	rows, err := repository.db.QueryContext(ctx, "select id, role, token_version from users where id = ? and deleted_at is null", ID)
+	if err != nil {
+		// We return an empty user if an error occurs
+		return models.User{}, err
+	}
+	defer rows.Close()
+
+	// Reading row data
+	var user models.User
+	if rows.Next() {
+		// Getting user
+		if err = rows.Scan(&user.ID, &user.Role, &user.TokenVersion); err != nil {
+			// We return an empty user if an error occurs
+			return models.User{}, err
+		}
+	}
+
+	// Returning the user data
+	return user, nil
+}
+
+// IncrementTokenVersion invalidates all tokens previously issued to a specific user
+func (repository Users) IncrementTokenVersion(ctx context.Context, ID uint64) error {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update users set token_version = token_version + 1 where id = ? and deleted_at is null",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	if _, err = statement.ExecContext(ctx, ID); err != nil {
+		return err
+	}
+
+	// Returning the function
+	return nil
+}
+
+// ChangeRole updates a specific user role by its ID
+func (repository Users) ChangeRole(ctx context.Context, ID uint64, role string) error {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update users set role = ? where id = ? and deleted_at is null",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	if _, err = statement.ExecContext(ctx, role, ID); err != nil {
+		return err
+	}
+
+	// Returning the function
+	return nil
+}
+
+// SearchTwoFactor returns a specific user two-factor authentication settings by its ID
+func (repository Users) SearchTwoFactor(ctx context.Context, ID uint64) (models.TwoFactor, error) {
+	// Executing the select statement
+	rows, err := repository.db.QueryContext(ctx,
+		"select coalesce(totp_secret, ''), totp_enabled, totp_last_step from users where id = ? and deleted_at is null", ID,
+	)
+	if err != nil {
+		// We return empty settings if an error occurs
+		return models.TwoFactor{}, err
+	}
+	defer rows.Close()
+
+	// Reading row data
+	var twoFactor models.TwoFactor
+	if rows.Next() {
+		// Getting settings
+		if err = rows.Scan(&twoFactor.Secret, &twoFactor.Enabled, &twoFactor.LastStep); err != nil {
+			// We return empty settings if an error occurs
+			return models.TwoFactor{}, err
+		}
+	}
+
+	// Returning the settings
+	return twoFactor, nil
+}
+
+// SetTwoFactorSecret stores a new (not yet enabled) TOTP secret for a specific user
+// It returns false if the two-factor authentication is already enabled for the user
+func (repository Users) SetTwoFactorSecret(ctx context.Context, ID uint64, secret string) (bool, error) {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update users set totp_secret = ?, totp_last_step = 0 where id = ? and totp_enabled = false and deleted_at is null",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	result, err := statement.ExecContext(ctx, secret, ID)
+	if err != nil {
+		return false, err
+	}
+
+	// Checking if the secret was actually stored
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	// Returning the function
+	return rowsAffected == 1, nil
+}
+
+// EnableTwoFactor enables the two-factor authentication for a specific user
+func (repository Users) EnableTwoFactor(ctx context.Context, ID uint64, lastStep int64) error {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update users set totp_enabled = true, totp_last_step = ? where id = ? and deleted_at is null",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	if _, err = statement.ExecContext(ctx, lastStep, ID); err != nil {
+		return err
+	}
+
+	// Returning the function
+	return nil
+}
+
+// DisableTwoFactor disables the two-factor authentication for a specific user, removing its secret
+func (repository Users) DisableTwoFactor(ctx context.Context, ID uint64) error {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update users set totp_enabled = false, totp_secret = null, totp_last_step = 0 where id = ? and deleted_at is null",
+	)
+	if err != nil {
+		return err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	if _, err = statement.ExecContext(ctx, ID); err != nil {
+		return err
+	}
+
+	// Returning the function
+	return nil
+}
+
+// UseTwoFactorStep registers the time step of an accepted TOTP code for a specific user
+// It returns false if a code from the same (or a later) time step was already used
+func (repository Users) UseTwoFactorStep(ctx context.Context, ID uint64, step int64) (bool, error) {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update users set totp_last_step = ? where id = ? and totp_last_step < ? and deleted_at is null",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	result, err := statement.ExecContext(ctx, step, ID, step)
+	if err != nil {
+		return false, err
+	}
+
+	// Checking if the time step was actually registered
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	// Returning the function
+	return rowsAffected == 1, nil
+}
+
+// VerifyEmail marks a specific user email as verified, if it's still the user's email
+// It returns false if the email was changed or had already been verified
+func (repository Users) VerifyEmail(ctx context.Context, ID uint64, email string) (bool, error) {
+	// Preparing the statement to execute the SQL query
+	statement, err := repository.db.PrepareContext(ctx,
+		"update users set email_verified_at = ? where id = ? and email = ? and email_verified_at is null and deleted_at is null",
+	)
+	if err != nil {
+		return false, err
+	}
+	defer statement.Close()
+
+	// Executing the update statement
+	result, err := statement.ExecContext(ctx, time.Now(), ID, email)
+	if err != nil {
+		return false, err
+	}
+
+	// Checking if the email was actually verified by this call
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	// Returning the function
+	return rowsAffected == 1, nil
+}
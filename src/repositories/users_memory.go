@@ -0,0 +1,444 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MemoryUsers represents an users repository, stored on the application memory
+// It has the same behavior as the database repository, so it can replace it (e.g. on tests)
+type MemoryUsers struct {
+	db *MemoryDatabase
+}
+
+// NewMemoryUsersRepository instantiates/initializes an in-memory users repository
+func NewMemoryUsersRepository(db *MemoryDatabase) *MemoryUsers {
+	return &MemoryUsers{db}
+}
+
+// Create is a MemoryUsers' method to create new users on the repository
+func (repository MemoryUsers) Create(ctx context.Context, user models.User) (uint64, error) {
+	repository.db.mutex.Lock()
+	defer repository.db.mutex.Unlock()
+
+	// Usernames and emails are unique
+	if repository.isTaken(0, user.Username, user.Email) {
+		return 0, ErrDuplicateEntry
+	}
+
+	// Storing the user with the database defaults
+	repository.db.lastUserID++
+	repository.db.users[repository.db.lastUserID] = &memoryUser{User: models.User{
+		ID:        repository.db.lastUserID,
+		Name:      user.Name,
+		Username:  user.Username,
+		Email:     user.Email,
+		Pass:      user.Pass,
+		Role:      "user",
+		CreatedAt: time.Now(),
+	}}
+
+	// Finally, we return the inserted user ID
+	return repository.db.lastUserID, nil
+}
+
+// Search a page of the users with specified name or username (case insensitive, ordered by their IDs)
+// It also returns whether there are more users beyond the page
+func (repository MemoryUsers) Search(ctx context.Context, nameOrUsername string, page models.PageRequest) ([]models.User, bool, error) {
+	repository.db.mutex.RLock()
+	defer repository.db.mutex.RUnlock()
+
+	nameOrUsername = strings.ToLower(nameOrUsername)
+	users, more := repository.page(func(user *memoryUser) bool {
+		return strings.Contains(strings.ToLower(user.Name), nameOrUsername) ||
+			strings.Contains(strings.ToLower(user.Username), nameOrUsername)
+	}, page)
+	return users, more, nil
+}
+
+// SearchByID a specific user by its ID
+func (repository MemoryUsers) SearchByID(ctx context.Context, ID uint64) (models.User, error) {
+	repository.db.mutex.RLock()
+	defer repository.db.mutex.RUnlock()
+
+	user := repository.db.activeUser(ID)
+	if user == nil {
+		return models.User{}, nil
+	}
+	profile := profileData(user)
+	profile.EmailVerifiedAt = user.EmailVerifiedAt
+	return profile, nil
+}
+
+// Update will edit a specific user data by its ID
+// If the email changes, it must be verified again
+func (repository MemoryUsers) Update(ctx context.Context, ID uint64, user models.User) error {
+	repository.db.mutex.Lock()
+	defer repository.db.mutex.Unlock()
+
+	savedUser := repository.db.activeUser(ID)
+	if savedUser == nil {
+		return nil
+	}
+	if repository.isTaken(ID, user.Username, user.Email) {
+		return ErrDuplicateEntry
+	}
+
+	if savedUser.Email != user.Email {
+		savedUser.EmailVerifiedAt = nil
+	}
+	savedUser.Name = user.Name
+	savedUser.Username = user.Username
+	savedUser.Email = user.Email
+	return nil
+}
+
+// Delete marks a specific user as deleted, hiding it (and its posts) until it's restored or purged
+func (repository MemoryUsers) Delete(ctx context.Context, ID uint64) error {
+	return repository.update(ID, func(user *memoryUser) {
+		now := time.Now()
+		user.DeletedAt = &now
+	})
+}
+
+// Restore brings back a specific deleted user (along with its posts)
+// It returns false if the user doesn't exist or isn't deleted
+func (repository MemoryUsers) Restore(ctx context.Context, ID uint64) (bool, error) {
+	repository.db.mutex.Lock()
+	defer repository.db.mutex.Unlock()
+
+	user, ok := repository.db.users[ID]
+	if !ok || user.DeletedAt == nil {
+		return false, nil
+	}
+	user.DeletedAt = nil
+	return true, nil
+}
+
+// Purge permanently removes the users deleted before the provided time, along with their follows, posts and likes
+// It returns how many users were removed
+func (repository MemoryUsers) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	repository.db.mutex.Lock()
+	defer repository.db.mutex.Unlock()
+
+	var purged int64
+	for ID, user := range repository.db.users {
+		if user.DeletedAt == nil || !user.DeletedAt.Before(deletedBefore) {
+			continue
+		}
+		delete(repository.db.users, ID)
+		for follow := range repository.db.followers {
+			if follow.UserID == ID || follow.FollowerID == ID {
+				delete(repository.db.followers, follow)
+			}
+		}
+		for postID, post := range repository.db.posts {
+			if post.AuthorID == ID {
+				delete(repository.db.posts, postID)
+			}
+		}
+		for like := range repository.db.likes {
+			post, ok := repository.db.posts[like.PostID]
+			if like.UserID == ID || !ok {
+				delete(repository.db.likes, like)
+				if ok {
+					repository.db.countLikes(post)
+				}
+			}
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// SearchByEmail a specific user by its email, as well as its hashpass (for login purposes)
+func (repository MemoryUsers) SearchByEmail(ctx context.Context, email string) (models.User, error) {
+	return repository.searchCredentials(func(user *memoryUser) bool {
+		return strings.EqualFold(user.Email, email)
+	}, false)
+}
+
+// SearchByUsername a specific user by its username, as well as its hashpass (for login purposes)
+func (repository MemoryUsers) SearchByUsername(ctx context.Context, username string) (models.User, error) {
+	return repository.searchCredentials(func(user *memoryUser) bool {
+		return strings.EqualFold(user.Username, username)
+	}, false)
+}
+
+// SearchDeletedByEmail a specific deleted user by its email, as well as its hashpass (for restoring purposes)
+func (repository MemoryUsers) SearchDeletedByEmail(ctx context.Context, email string) (models.User, error) {
+	return repository.searchCredentials(func(user *memoryUser) bool {
+		return strings.EqualFold(user.Email, email)
+	}, true)
+}
+
+// SearchDeletedByUsername a specific deleted user by its username, as well as its hashpass (for restoring purposes)
+func (repository MemoryUsers) SearchDeletedByUsername(ctx context.Context, username string) (models.User, error) {
+	return repository.searchCredentials(func(user *memoryUser) bool {
+		return strings.EqualFold(user.Username, username)
+	}, true)
+}
+
+// Follow allows an user to follow another one (following twice is ignored)
+func (repository MemoryUsers) Follow(ctx context.Context, userID, followerID uint64) error {
+	repository.db.mutex.Lock()
+	defer repository.db.mutex.Unlock()
+
+	// The follower must exist, and missing or deleted users can't be followed (the follow is ignored)
+	if repository.db.users[followerID] == nil {
+		return ErrForeignKeyViolation
+	}
+	if repository.db.activeUser(userID) == nil {
+		return nil
+	}
+	repository.db.followers[follow{userID, followerID}] = true
+	return nil
+}
+
+// Unfollow allows an user to stop following another one
+func (repository MemoryUsers) Unfollow(ctx context.Context, userID, followerID uint64) error {
+	repository.db.mutex.Lock()
+	defer repository.db.mutex.Unlock()
+
+	delete(repository.db.followers, follow{userID, followerID})
+	return nil
+}
+
+// SearchFollowers returns a page of an user followers by its ID (ordered by their IDs)
+// It also returns whether there are more followers beyond the page
+func (repository MemoryUsers) SearchFollowers(ctx context.Context, userID uint64, page models.PageRequest) ([]models.User, bool, error) {
+	repository.db.mutex.RLock()
+	defer repository.db.mutex.RUnlock()
+
+	users, more := repository.page(func(user *memoryUser) bool {
+		return repository.db.followers[follow{userID, user.ID}]
+	}, page)
+	return users, more, nil
+}
+
+// SearchFollowing returns a page of the users followed by another one (ordered by their IDs)
+// It also returns whether there are more users beyond the page
+func (repository MemoryUsers) SearchFollowing(ctx context.Context, userID uint64, page models.PageRequest) ([]models.User, bool, error) {
+	repository.db.mutex.RLock()
+	defer repository.db.mutex.RUnlock()
+
+	users, more := repository.page(func(user *memoryUser) bool {
+		return repository.db.followers[follow{user.ID, userID}]
+	}, page)
+	return users, more, nil
+}
+
+// SearchLikers returns a page of the users who like a post (ordered by their IDs)
+// It also returns whether there are more users beyond the page
+func (repository MemoryUsers) SearchLikers(ctx context.Context, postID uint64, page models.PageRequest) ([]models.User, bool, error) {
+	repository.db.mutex.RLock()
+	defer repository.db.mutex.RUnlock()
+
+	users, more := repository.page(func(user *memoryUser) bool {
+		return repository.db.likes[like{postID, user.ID}]
+	}, page)
+	return users, more, nil
+}
+
+// SearchPassword returns a specific user password by its ID
+func (repository MemoryUsers) SearchPassword(ctx context.Context, userID uint64) (string, error) {
+	repository.db.mutex.RLock()
+	defer repository.db.mutex.RUnlock()
+
+	if user := repository.db.activeUser(userID); user != nil {
+		return user.Pass, nil
+	}
+	return "", nil
+}
+
+// ChangePassword updates a specific user password
+func (repository MemoryUsers) ChangePassword(ctx context.Context, userID uint64, hashPass string) error {
+	return repository.update(userID, func(user *memoryUser) {
+		user.Pass = hashPass
+	})
+}
+
+// ReplacePassword updates a specific user password, if it's still the provided current (hashed) password
+// It returns false if the password was changed in the meantime
+func (repository MemoryUsers) ReplacePassword(ctx context.Context, userID uint64, currentHashPass, newHashPass string) (bool, error) {
+	return repository.updateIf(userID, func(user *memoryUser) bool {
+		if user.Pass != currentHashPass {
+			return false
+		}
+		user.Pass = newHashPass
+		return true
+	})
+}
+
+// SearchTokenData returns a specific user ID, role and token version by its ID (the data carried by tokens)
+// If the user doesn't exist (e.g. it was deleted), an empty user is returned
+func (repository MemoryUsers) SearchTokenData(ctx context.Context, ID uint64) (models.User, error) {
+	repository.db.mutex.RLock()
+	defer repository.db.mutex.RUnlock()
+
+	if user := repository.db.activeUser(ID); user != nil {
+		return models.User{ID: user.ID, Role: user.Role, TokenVersion: user.TokenVersion}, nil
+	}
+	return models.User{}, nil
+}
+
+// IncrementTokenVersion invalidates all tokens previously issued to a specific user
+func (repository MemoryUsers) IncrementTokenVersion(ctx context.Context, ID uint64) error {
+	return repository.update(ID, func(user *memoryUser) {
+		user.TokenVersion++
+	})
+}
+
+// ChangeRole updates a specific user role by its ID
+func (repository MemoryUsers) ChangeRole(ctx context.Context, ID uint64, role string) error {
+	return repository.update(ID, func(user *memoryUser) {
+		user.Role = role
+	})
+}
+
+// SearchTwoFactor returns a specific user two-factor authentication settings by its ID
+func (repository MemoryUsers) SearchTwoFactor(ctx context.Context, ID uint64) (models.TwoFactor, error) {
+	repository.db.mutex.RLock()
+	defer repository.db.mutex.RUnlock()
+
+	if user := repository.db.activeUser(ID); user != nil {
+		return user.TwoFactor, nil
+	}
+	return models.TwoFactor{}, nil
+}
+
+// SetTwoFactorSecret stores a new (not yet enabled) TOTP secret for a specific user
+// It returns false if the two-factor authentication is already enabled for the user
+func (repository MemoryUsers) SetTwoFactorSecret(ctx context.Context, ID uint64, secret string) (bool, error) {
+	return repository.updateIf(ID, func(user *memoryUser) bool {
+		if user.TwoFactor.Enabled {
+			return false
+		}
+		user.TwoFactor = models.TwoFactor{Secret: secret}
+		return true
+	})
+}
+
+// EnableTwoFactor enables the two-factor authentication for a specific user
+func (repository MemoryUsers) EnableTwoFactor(ctx context.Context, ID uint64, lastStep int64) error {
+	return repository.update(ID, func(user *memoryUser) {
+		user.TwoFactor.Enabled = true
+		user.TwoFactor.LastStep = lastStep
+	})
+}
+
+// DisableTwoFactor disables the two-factor authentication for a specific user, removing its secret
+func (repository MemoryUsers) DisableTwoFactor(ctx context.Context, ID uint64) error {
+	return repository.update(ID, func(user *memoryUser) {
+		user.TwoFactor = models.TwoFactor{}
+	})
+}
+
+// UseTwoFactorStep registers the time step of an accepted TOTP code for a specific user
+// It returns false if a code from the same (or a later) time step was already used
+func (repository MemoryUsers) UseTwoFactorStep(ctx context.Context, ID uint64, step int64) (bool, error) {
+	return repository.updateIf(ID, func(user *memoryUser) bool {
+		if user.TwoFactor.LastStep >= step {
+			return false
+		}
+		user.TwoFactor.LastStep = step
+		return true
+	})
+}
+
+// VerifyEmail marks a specific user email as verified, if it's still the user's email
+// It returns false if the email was changed or had already been verified
+func (repository MemoryUsers) VerifyEmail(ctx context.Context, ID uint64, email string) (bool, error) {
+	return repository.updateIf(ID, func(user *memoryUser) bool {
+		if user.Email != email || user.EmailVerifiedAt != nil {
+			return false
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		return true
+	})
+}
+
+// searchCredentials searchs a specific user (either active or deleted) matching the condition, as well as its hashpass
+func (repository MemoryUsers) searchCredentials(matches func(user *memoryUser) bool, deleted bool) (models.User, error) {
+	repository.db.mutex.RLock()
+	defer repository.db.mutex.RUnlock()
+
+	for _, user := range repository.db.users {
+		if (user.DeletedAt != nil) == deleted && matches(user) {
+			return models.User{
+				ID:               user.ID,
+				Pass:             user.Pass,
+				Role:             user.Role,
+				TokenVersion:     user.TokenVersion,
+				TwoFactorEnabled: user.TwoFactor.Enabled,
+			}, nil
+		}
+	}
+	return models.User{}, nil
+}
+
+// filter returns the profile of the (not deleted) users matching the condition, ordered by their IDs
+// The mutex must be locked by the caller
+func (repository MemoryUsers) filter(matches func(user *memoryUser) bool) []models.User {
+	var users []models.User
+	for _, user := range repository.db.users {
+		if user.DeletedAt == nil && matches(user) {
+			users = append(users, profileData(user))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users
+}
+
+// page returns a page of the profile of the users matching the condition, ordered by their IDs
+// It also returns whether there are more users beyond the page. The mutex must be locked by the caller
+func (repository MemoryUsers) page(matches func(user *memoryUser) bool, page models.PageRequest) ([]models.User, bool) {
+	return memoryPage(repository.filter(matches), func(user models.User) uint64 { return user.ID }, false, page)
+}
+
+// update changes a specific user, if it exists (and wasn't deleted)
+func (repository MemoryUsers) update(ID uint64, change func(user *memoryUser)) error {
+	_, err := repository.updateIf(ID, func(user *memoryUser) bool {
+		change(user)
+		return true
+	})
+	return err
+}
+
+// updateIf changes a specific user, if it exists (and wasn't deleted), returning whether it was changed
+func (repository MemoryUsers) updateIf(ID uint64, change func(user *memoryUser) bool) (bool, error) {
+	repository.db.mutex.Lock()
+	defer repository.db.mutex.Unlock()
+
+	user := repository.db.activeUser(ID)
+	if user == nil {
+		return false, nil
+	}
+	return change(user), nil
+}
+
+// isTaken checks if the username or email is used by another user (ignoring the user with the provided ID)
+// The mutex must be locked by the caller
+func (repository MemoryUsers) isTaken(ID uint64, username, email string) bool {
+	for _, user := range repository.db.users {
+		if user.ID != ID && (strings.EqualFold(user.Username, username) || strings.EqualFold(user.Email, email)) {
+			return true
+		}
+	}
+	return false
+}
+
+// profileData returns the user data returned on searches (without the password)
+func profileData(user *memoryUser) models.User {
+	return models.User{
+		ID:        user.ID,
+		Name:      user.Name,
+		Username:  user.Username,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt,
+	}
+}
@@ -1,11 +1,16 @@
 package responses
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 )
 
+// StatusClientClosedRequest is the (non-standard) status code of requests cancelled by the client
+const StatusClientClosedRequest = 499
+
 // JSON returns a JSON response to the request
 func JSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	// Setting the content type
@@ -23,7 +28,19 @@ func JSON(w http.ResponseWriter, statusCode int, data interface{}) {
 }
 
 // Error returns a JSON formatted error response message to the request
+// Internal errors caused by a cancelled request (e.g. the client disconnected) or by a query timeout
+// aren't reported as server failures
 func Error(w http.ResponseWriter, statusCode int, err error) {
+	if statusCode == http.StatusInternalServerError {
+		switch {
+		case errors.Is(err, context.Canceled):
+			statusCode = StatusClientClosedRequest
+		case errors.Is(err, context.DeadlineExceeded):
+			statusCode = http.StatusServiceUnavailable
+			err = errors.New("The request took too long, please try again later")
+		}
+	}
+
 	// Formats data and call the JSOn function
 	JSON(w, statusCode, struct {
 		Error string `json:"error"`
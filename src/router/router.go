@@ -1,16 +1,18 @@
 package router
 
 import (
+	"api/src/controllers"
+	"api/src/middlewares"
 	"api/src/router/routes"
 
 	"github.com/gorilla/mux"
 )
 
 // Generate will return a router with set up routes
-func Generate() *mux.Router {
+func Generate(handler *controllers.Handler, m *middlewares.Middlewares) *mux.Router {
 	// Initializing the router
 	r := mux.NewRouter()
 
 	// Returning the configured router
-	return routes.SetUp(r)
+	return routes.SetUp(r, handler, m)
 }
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"api/src/authentication"
+	"api/src/controllers"
+	"net/http"
+)
+
+// Defining the admin routes
+func adminRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                    "/users/{userId}/role",
+			Method:                 http.MethodPut,
+			Function:               handler.ChangeUserRole,
+			RequiresAuthentication: true,
+			Roles:                  []string{authentication.RoleAdmin},
+		},
+		{
+			URI:                    "/users/{userId}/revoke-tokens",
+			Method:                 http.MethodPost,
+			Function:               handler.RevokeUserTokens,
+			RequiresAuthentication: true,
+			Permissions:            []string{authentication.PermissionManageUsers},
+		},
+		{
+			URI:                    "/users/{userId}/restore",
+			Method:                 http.MethodPost,
+			Function:               handler.RestoreUser,
+			RequiresAuthentication: true,
+			Permissions:            []string{authentication.PermissionManageUsers},
+		},
+	}
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+// Defining the email verification routes
+func emailVerificationRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                    "/verify-email",
+			Method:                 http.MethodPost,
+			Function:               handler.VerifyEmail,
+			RequiresAuthentication: false,
+		},
+		{
+			URI:                    "/users/{userId}/verify-email/resend",
+			Method:                 http.MethodPost,
+			Function:               handler.ResendVerificationEmail,
+			RequiresAuthentication: true,
+		},
+	}
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func jwksRoute(handler *controllers.Handler) Route {
+	return Route{
+		URI:                    "/.well-known/jwks.json",
+		Method:                 http.MethodGet,
+		Function:               handler.SearchJWKS,
+		RequiresAuthentication: false,
+	}
+}
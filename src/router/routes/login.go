@@ -5,9 +5,11 @@ import (
 	"net/http"
 )
 
-var loginRoute = Route{
-	URI:                    "/login",
-	Method:                 http.MethodPost,
-	Function:               controllers.Login,
-	RequiresAuthentication: false,
+func loginRoute(handler *controllers.Handler) Route {
+	return Route{
+		URI:                    "/login",
+		Method:                 http.MethodPost,
+		Function:               handler.Login,
+		RequiresAuthentication: false,
+	}
 }
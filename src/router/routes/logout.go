@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+// Defining the logout routes
+func logoutRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                    "/logout",
+			Method:                 http.MethodPost,
+			Function:               handler.Logout,
+			RequiresAuthentication: true,
+		},
+		{
+			URI:                    "/logout-all",
+			Method:                 http.MethodPost,
+			Function:               handler.LogoutAll,
+			RequiresAuthentication: true,
+		},
+	}
+}
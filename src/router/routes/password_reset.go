@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+// Defining the password reset routes
+func passwordResetRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                    "/password/forgot",
+			Method:                 http.MethodPost,
+			Function:               handler.ForgotPassword,
+			RequiresAuthentication: false,
+		},
+		{
+			URI:                    "/password/reset",
+			Method:                 http.MethodPost,
+			Function:               handler.ResetPassword,
+			RequiresAuthentication: false,
+		},
+	}
+}
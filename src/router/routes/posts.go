@@ -1,58 +1,91 @@
 package routes
 
 import (
+	"api/src/authentication"
 	"api/src/controllers"
 	"net/http"
 )
 
 // Defining the posts routes
-var postsRoutes = []Route{
-	{
-		URI:                    "/posts",
-		Method:                 http.MethodPost,
-		Function:               controllers.CreatePost,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/posts",
-		Method:                 http.MethodGet,
-		Function:               controllers.SearchPosts,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/posts/{postId}",
-		Method:                 http.MethodGet,
-		Function:               controllers.SearchPost,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/posts/{postId}",
-		Method:                 http.MethodPut,
-		Function:               controllers.UpdatePost,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/posts/{postId}",
-		Method:                 http.MethodDelete,
-		Function:               controllers.DeletePost,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/users/{userId}/posts",
-		Method:                 http.MethodGet,
-		Function:               controllers.SearchPostsByUser,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/posts/{postId}/like",
-		Method:                 http.MethodPost,
-		Function:               controllers.LikePost,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/posts/{postId}/dislike",
-		Method:                 http.MethodPost,
-		Function:               controllers.DislikePost,
-		RequiresAuthentication: true,
-	},
+func postsRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                    "/posts",
+			Method:                 http.MethodPost,
+			Function:               handler.CreatePost,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopePostsWrite},
+			RequiresVerifiedEmail:  true,
+		},
+		{
+			URI:                    "/posts",
+			Method:                 http.MethodGet,
+			Function:               handler.SearchPosts,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopePostsRead},
+		},
+		{
+			URI:                    "/posts/search",
+			Method:                 http.MethodGet,
+			Function:               handler.SearchPostsByKeywords,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopePostsRead},
+		},
+		{
+			URI:                    "/posts/{postId}",
+			Method:                 http.MethodGet,
+			Function:               handler.SearchPost,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopePostsRead},
+		},
+		{
+			URI:                    "/posts/{postId}",
+			Method:                 http.MethodPut,
+			Function:               handler.UpdatePost,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopePostsWrite},
+		},
+		{
+			URI:                    "/posts/{postId}",
+			Method:                 http.MethodDelete,
+			Function:               handler.DeletePost,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopePostsWrite},
+		},
+		{
+			URI:                    "/posts/{postId}/restore",
+			Method:                 http.MethodPost,
+			Function:               handler.RestorePost,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopePostsWrite},
+		},
+		{
+			URI:                    "/users/{userId}/posts",
+			Method:                 http.MethodGet,
+			Function:               handler.SearchPostsByUser,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopePostsRead},
+		},
+		{
+			URI:                    "/posts/{postId}/like",
+			Method:                 http.MethodPost,
+			Function:               handler.LikePost,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopePostsWrite},
+		},
+		{
+			URI:                    "/posts/{postId}/dislike",
+			Method:                 http.MethodPost,
+			Function:               handler.DislikePost,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopePostsWrite},
+		},
+		{
+			URI:                    "/posts/{postId}/likes",
+			Method:                 http.MethodGet,
+			Function:               handler.SearchPostLikes,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopePostsRead},
+		},
+	}
 }
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+func refreshRoute(handler *controllers.Handler) Route {
+	return Route{
+		URI:                    "/refresh",
+		Method:                 http.MethodPost,
+		Function:               handler.RefreshToken,
+		RequiresAuthentication: false,
+	}
+}
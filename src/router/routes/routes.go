@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"api/src/controllers"
 	"api/src/middlewares"
 	"net/http"
 
@@ -13,31 +14,68 @@ type Route struct {
 	Method                 string
 	Function               func(http.ResponseWriter, *http.Request)
 	RequiresAuthentication bool
+	// User must have at least one of these roles (if any is defined)
+	Roles []string
+	// User must have all of these permissions (granted by its role)
+	Permissions []string
+	// Scopes required from personal access tokens (routes without scopes don't accept them)
+	Scopes []string
+	// User must have confirmed the email address
+	RequiresVerifiedEmail bool
 }
 
-// SetUp adds all routes to the router
-func SetUp(r *mux.Router) *mux.Router {
+// SetUp adds all routes to the router, served by the provided handler and middlewares
+func SetUp(r *mux.Router, h *controllers.Handler, m *middlewares.Middlewares) *mux.Router {
 	// Getting the users routes
-	routes := usersRoutes
+	routes := usersRoutes(h)
 	// Getting login route
-	routes = append(routes, loginRoute)
+	routes = append(routes, loginRoute(h))
+	// Getting refresh token route
+	routes = append(routes, refreshRoute(h))
+	// Getting logout routes
+	routes = append(routes, logoutRoutes(h)...)
+	// Getting public keys route
+	routes = append(routes, jwksRoute(h))
 	// Getting posts routes
-	routes = append(routes, postsRoutes...)
+	routes = append(routes, postsRoutes(h)...)
+	// Getting admin routes
+	routes = append(routes, adminRoutes(h)...)
+	// Getting personal access tokens routes
+	routes = append(routes, personalAccessTokensRoutes(h)...)
+	// Getting two-factor authentication routes
+	routes = append(routes, twoFactorRoutes(h)...)
+	// Getting email verification routes
+	routes = append(routes, emailVerificationRoutes(h)...)
+	// Getting password reset routes
+	routes = append(routes, passwordResetRoutes(h)...)
+	// Getting sessions routes
+	routes = append(routes, sessionsRoutes(h)...)
 
 	// For each created route
 	for _, route := range routes {
-		// If route requires authentication
+		handler := http.HandlerFunc(route.Function)
+
+		// If route requires a verified email, the email verification middleware is used
+		if route.RequiresVerifiedEmail {
+			handler = m.RequireVerifiedEmail(handler)
+			// The verification is checked for the authenticated user
+			route.RequiresAuthentication = true
+		}
+
+		// If route requires roles or permissions, the authorization middleware is used
+		if len(route.Roles) > 0 || len(route.Permissions) > 0 {
+			handler = middlewares.Authorize(route.Roles, route.Permissions, handler)
+			// Authorization depends on the authenticated user
+			route.RequiresAuthentication = true
+		}
+
+		// If route requires authentication, the authentication and scopes middlewares are used
 		if route.RequiresAuthentication {
-			// Setting the handler function for the route, using the logger and authentication middlewares
-			r.HandleFunc(route.URI,
-				middlewares.Logger(middlewares.Authenticate(route.Function)),
-			).Methods(route.Method)
-		} else {
-			// Setting the handler function for the route, using the logger middleware
-			r.HandleFunc(route.URI,
-				middlewares.Logger(route.Function),
-			).Methods(route.Method)
+			handler = m.Authenticate(middlewares.RequireScopes(route.Scopes, handler))
 		}
+
+		// Setting the handler function for the route, using the logger middleware
+		r.HandleFunc(route.URI, middlewares.Logger(handler)).Methods(route.Method)
 	}
 
 	// Returning the new router
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+// Defining the sessions routes
+// These routes have no scopes, so personal access tokens can't be used to manage sessions
+func sessionsRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                    "/users/{userId}/sessions",
+			Method:                 http.MethodGet,
+			Function:               handler.SearchSessions,
+			RequiresAuthentication: true,
+		},
+		{
+			URI:                    "/users/{userId}/sessions/{sessionId}",
+			Method:                 http.MethodDelete,
+			Function:               handler.RevokeSession,
+			RequiresAuthentication: true,
+		},
+	}
+}
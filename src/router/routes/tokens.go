@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+// Defining the personal access tokens routes
+// These routes have no scopes, so personal access tokens can't be used to manage other tokens
+func personalAccessTokensRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                    "/users/{userId}/tokens",
+			Method:                 http.MethodPost,
+			Function:               handler.CreatePersonalAccessToken,
+			RequiresAuthentication: true,
+		},
+		{
+			URI:                    "/users/{userId}/tokens",
+			Method:                 http.MethodGet,
+			Function:               handler.SearchPersonalAccessTokens,
+			RequiresAuthentication: true,
+		},
+		{
+			URI:                    "/users/{userId}/tokens/{tokenId}",
+			Method:                 http.MethodDelete,
+			Function:               handler.RevokePersonalAccessToken,
+			RequiresAuthentication: true,
+		},
+	}
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+// Defining the two-factor authentication routes
+func twoFactorRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                    "/login/2fa",
+			Method:                 http.MethodPost,
+			Function:               handler.LoginTwoFactor,
+			RequiresAuthentication: false,
+		},
+		{
+			URI:                    "/users/{userId}/2fa/enroll",
+			Method:                 http.MethodPost,
+			Function:               handler.EnrollTwoFactor,
+			RequiresAuthentication: true,
+		},
+		{
+			URI:                    "/users/{userId}/2fa/enable",
+			Method:                 http.MethodPost,
+			Function:               handler.EnableTwoFactor,
+			RequiresAuthentication: true,
+		},
+		{
+			URI:                    "/users/{userId}/2fa/disable",
+			Method:                 http.MethodPost,
+			Function:               handler.DisableTwoFactor,
+			RequiresAuthentication: true,
+		},
+	}
+}
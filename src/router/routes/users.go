@@ -1,70 +1,87 @@
 package routes
 
 import (
+	"api/src/authentication"
 	"api/src/controllers"
 	"net/http"
 )
 
 // Defining the users routes
-var usersRoutes = []Route{
-	{
-		URI:                    "/users",
-		Method:                 http.MethodPost,
-		Function:               controllers.CreateUser,
-		RequiresAuthentication: false,
-	},
-	{
-		URI:                    "/users",
-		Method:                 http.MethodGet,
-		Function:               controllers.SearchUsers,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/users/{userId}",
-		Method:                 http.MethodGet,
-		Function:               controllers.SearchUser,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/users/{userId}",
-		Method:                 http.MethodPut,
-		Function:               controllers.UpdateUser,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/users/{userId}",
-		Method:                 http.MethodDelete,
-		Function:               controllers.DeleteUser,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/users/{userId}/follow",
-		Method:                 http.MethodPost,
-		Function:               controllers.FollowUser,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/users/{userId}/unfollow",
-		Method:                 http.MethodDelete,
-		Function:               controllers.UnfollowUser,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/users/{userId}/followers",
-		Method:                 http.MethodGet,
-		Function:               controllers.SearchFollowers,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/users/{userId}/following",
-		Method:                 http.MethodGet,
-		Function:               controllers.SearchFollowing,
-		RequiresAuthentication: true,
-	},
-	{
-		URI:                    "/users/{userId}/change-password",
-		Method:                 http.MethodPost,
-		Function:               controllers.ChangePassword,
-		RequiresAuthentication: true,
-	},
+func usersRoutes(handler *controllers.Handler) []Route {
+	return []Route{
+		{
+			URI:                    "/users",
+			Method:                 http.MethodPost,
+			Function:               handler.CreateUser,
+			RequiresAuthentication: false,
+		},
+		{
+			URI:                    "/users",
+			Method:                 http.MethodGet,
+			Function:               handler.SearchUsers,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopeUsersRead},
+		},
+		{
+			URI:                    "/users/{userId}",
+			Method:                 http.MethodGet,
+			Function:               handler.SearchUser,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopeUsersRead},
+		},
+		{
+			URI:                    "/users/{userId}",
+			Method:                 http.MethodPut,
+			Function:               handler.UpdateUser,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopeUsersWrite},
+		},
+		{
+			URI:                    "/users/{userId}",
+			Method:                 http.MethodDelete,
+			Function:               handler.DeleteUser,
+			RequiresAuthentication: true,
+		},
+		{
+			URI:                    "/users/restore",
+			Method:                 http.MethodPost,
+			Function:               handler.RestoreAccount,
+			RequiresAuthentication: false,
+		},
+		{
+			URI:                    "/users/{userId}/follow",
+			Method:                 http.MethodPost,
+			Function:               handler.FollowUser,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopeFollow},
+			RequiresVerifiedEmail:  true,
+		},
+		{
+			URI:                    "/users/{userId}/unfollow",
+			Method:                 http.MethodDelete,
+			Function:               handler.UnfollowUser,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopeFollow},
+		},
+		{
+			URI:                    "/users/{userId}/followers",
+			Method:                 http.MethodGet,
+			Function:               handler.SearchFollowers,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopeUsersRead},
+		},
+		{
+			URI:                    "/users/{userId}/following",
+			Method:                 http.MethodGet,
+			Function:               handler.SearchFollowing,
+			RequiresAuthentication: true,
+			Scopes:                 []string{authentication.ScopeUsersRead},
+		},
+		{
+			URI:                    "/users/{userId}/change-password",
+			Method:                 http.MethodPost,
+			Function:               handler.ChangePassword,
+			RequiresAuthentication: true,
+		},
+	}
 }
@@ -0,0 +1,137 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2idParams defines the argon2id cost parameters
+type Argon2idParams struct {
+	// Number of passes over the memory
+	Time uint32
+	// Memory used, in KiB
+	Memory uint32
+	// Number of threads used
+	Threads uint8
+	// Sizes of the salt and of the generated key, in bytes
+	SaltLength uint32
+	KeyLength  uint32
+}
+
+// Argon2idHasher hashes passwords with argon2id, encoding them on the PHC string format
+// e.g. $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+// NewArgon2idHasher instantiates/initializes an argon2id hasher (the recommended values are used for missing parameters)
+func NewArgon2idHasher(params Argon2idParams) *Argon2idHasher {
+	if params.Time == 0 {
+		params.Time = 2
+	}
+	if params.Memory == 0 {
+		params.Memory = 19 * 1024
+	}
+	if params.Threads == 0 {
+		params.Threads = 1
+	}
+	if params.SaltLength == 0 {
+		params.SaltLength = 16
+	}
+	if params.KeyLength == 0 {
+		params.KeyLength = 32
+	}
+	return &Argon2idHasher{params}
+}
+
+// Hash creates an argon2id hash for the password, with a random salt
+func (hasher *Argon2idHasher) Hash(pass string) (string, error) {
+	// Generating the salt
+	salt := make([]byte, hasher.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	// Creating the key and encoding it with the parameters
+	key := argon2.IDKey([]byte(pass), salt, hasher.params.Time, hasher.params.Memory, hasher.params.Threads, hasher.params.KeyLength)
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		hasher.params.Memory,
+		hasher.params.Time,
+		hasher.params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Recognizes checks if the hash is an argon2id hash
+func (hasher *Argon2idHasher) Recognizes(hashPass string) bool {
+	return strings.HasPrefix(hashPass, "$argon2id$")
+}
+
+// Check verifies if the password matches the argon2id hash, using the parameters encoded on it
+func (hasher *Argon2idHasher) Check(pass, hashPass string) error {
+	params, salt, key, err := decodeArgon2id(hashPass)
+	if err != nil {
+		return err
+	}
+
+	// Comparing the keys in constant time
+	otherKey := argon2.IDKey([]byte(pass), salt, params.Time, params.Memory, params.Threads, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, otherKey) != 1 {
+		return errors.New("Password doesn't match the hash")
+	}
+	return nil
+}
+
+// IsCurrent checks if the hash was created with the hasher parameters
+func (hasher *Argon2idHasher) IsCurrent(hashPass string) bool {
+	params, _, _, err := decodeArgon2id(hashPass)
+	return err == nil && params == hasher.params
+}
+
+// decodeArgon2id reads the parameters, the salt and the key from an argon2id hash
+func decodeArgon2id(hashPass string) (Argon2idParams, []byte, []byte, error) {
+	invalidHash := errors.New("Invalid argon2id hash")
+
+	// Expected parts: "", "argon2id", version, parameters, salt, key
+	parts := strings.Split(hashPass, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2idParams{}, nil, nil, invalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return Argon2idParams{}, nil, nil, invalidHash
+	}
+	if version != argon2.Version {
+		return Argon2idParams{}, nil, nil, fmt.Errorf("Unsupported argon2id version! %d", version)
+	}
+
+	// Reading the cost parameters
+	var params Argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil ||
+		params.Time == 0 || params.Threads == 0 {
+		return Argon2idParams{}, nil, nil, invalidHash
+	}
+
+	// Reading the salt and the key
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2idParams{}, nil, nil, invalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2idParams{}, nil, nil, invalidHash
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
@@ -0,0 +1,42 @@
+package security
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// BcryptHasher hashes passwords with bcrypt
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher instantiates/initializes a bcrypt hasher (the default cost is used if it's not valid)
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{cost}
+}
+
+// Hash creates a bcrypt hash for the password
+func (hasher *BcryptHasher) Hash(pass string) (string, error) {
+	hashPass, err := bcrypt.GenerateFromPassword([]byte(pass), hasher.cost)
+	return string(hashPass), err
+}
+
+// Recognizes checks if the hash is a bcrypt hash ($2a$, $2b$ or $2y$)
+func (hasher *BcryptHasher) Recognizes(hashPass string) bool {
+	return strings.HasPrefix(hashPass, "$2a$") || strings.HasPrefix(hashPass, "$2b$") || strings.HasPrefix(hashPass, "$2y$")
+}
+
+// Check verifies if the password matches the bcrypt hash
+func (hasher *BcryptHasher) Check(pass, hashPass string) error {
+	return bcrypt.CompareHashAndPassword([]byte(hashPass), []byte(pass))
+}
+
+// IsCurrent checks if the hash was created with the hasher cost
+func (hasher *BcryptHasher) IsCurrent(hashPass string) bool {
+	cost, err := bcrypt.Cost([]byte(hashPass))
+	return err == nil && cost == hasher.cost
+}
@@ -0,0 +1,62 @@
+package security
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BreachedPasswords represents a source of passwords known from data breaches
+type BreachedPasswords interface {
+	Contains(password string) (bool, error)
+}
+
+// HashPrefixDirectory checks passwords against a directory of SHA-1 hash ranges (k-anonymity style)
+// Each file is named after the first 5 hex characters of the hashes it holds (e.g. "5BAA6"), and has
+// one "SUFFIX:COUNT" line per hash, which is the format returned by the Have I Been Pwned range API
+// Only the file matching the password hash prefix is read, so the whole list is never loaded on memory
+type HashPrefixDirectory struct {
+	path string
+}
+
+// NewHashPrefixDirectory instantiates/initializes a breached passwords directory
+func NewHashPrefixDirectory(path string) *HashPrefixDirectory {
+	return &HashPrefixDirectory{path}
+}
+
+// Contains checks if the password hash is listed on the directory
+func (directory *HashPrefixDirectory) Contains(password string) (bool, error) {
+	// Splitting the password hash into its prefix and suffix
+	hash := sha1.Sum([]byte(password))
+	hexHash := strings.ToUpper(hex.EncodeToString(hash[:]))
+	prefix, suffix := hexHash[:5], hexHash[5:]
+
+	// Opening the range file (hashes without a file are not listed)
+	file, err := os.Open(filepath.Join(directory.path, prefix))
+	if os.IsNotExist(err) {
+		file, err = os.Open(filepath.Join(directory.path, prefix+".txt"))
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	// Searching the suffix on the file lines
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if colon := strings.IndexByte(line, ':'); colon >= 0 {
+			line = line[:colon]
+		}
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
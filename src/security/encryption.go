@@ -0,0 +1,76 @@
+package security
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// encryptedSecretPrefix identifies the encrypted TOTP secrets
+// Secrets stored before they were encrypted have no prefix, and are read as they are
+const encryptedSecretPrefix = "enc:"
+
+// totpSecretsCipher encrypts the TOTP secrets stored on the database (set up when the application starts)
+var totpSecretsCipher cipher.AEAD
+
+// SetTOTPEncryptionKey defines the key used to encrypt the TOTP secrets (an AES-256 key is derived from it)
+func SetTOTPEncryptionKey(key []byte) error {
+	if len(key) == 0 {
+		return errors.New("TOTP_ENCRYPTION_KEY must be set to store two-factor authentication secrets")
+	}
+
+	// Creating the AES-GCM cipher
+	derivedKey := sha256.Sum256(key)
+	block, err := aes.NewCipher(derivedKey[:])
+	if err != nil {
+		return err
+	}
+	totpSecretsCipher, err = cipher.NewGCM(block)
+	return err
+}
+
+// EncryptTOTPSecret encrypts a TOTP secret, so it can be stored on the database
+func EncryptTOTPSecret(secret string) (string, error) {
+	if totpSecretsCipher == nil {
+		return "", errors.New("The TOTP encryption key is not set")
+	}
+
+	// Generating a random nonce, which is stored before the encrypted secret
+	nonce := make([]byte, totpSecretsCipher.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	encrypted := totpSecretsCipher.Seal(nonce, nonce, []byte(secret), nil)
+	return encryptedSecretPrefix + base64.RawStdEncoding.EncodeToString(encrypted), nil
+}
+
+// DecryptTOTPSecret decrypts a TOTP secret read from the database
+func DecryptTOTPSecret(storedSecret string) (string, error) {
+	if !strings.HasPrefix(storedSecret, encryptedSecretPrefix) {
+		return storedSecret, nil
+	}
+	if totpSecretsCipher == nil {
+		return "", errors.New("The TOTP encryption key is not set")
+	}
+
+	// Splitting the nonce and the encrypted secret
+	encrypted, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(storedSecret, encryptedSecretPrefix))
+	if err != nil {
+		return "", err
+	}
+	nonceSize := totpSecretsCipher.NonceSize()
+	if len(encrypted) < nonceSize {
+		return "", errors.New("Invalid encrypted TOTP secret")
+	}
+
+	// Decrypting the secret (which fails if it was changed, or encrypted with another key)
+	secret, err := totpSecretsCipher.Open(nil, encrypted[:nonceSize], encrypted[nonceSize:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
@@ -0,0 +1,127 @@
+package security
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Defining the character classes which can be required on passwords
+const (
+	ClassLower  = "lower"
+	ClassUpper  = "upper"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+)
+
+// Defining the password policy rules (reported when a password doesn't follow them)
+const (
+	RuleMinLength    = "min-length"
+	RuleMaxLength    = "max-length"
+	RuleClass        = "character-class"
+	RulePersonalData = "personal-data"
+	RuleBreached     = "breached"
+)
+
+// maxPasswordLength is the maximum password size, in bytes (bcrypt ignores anything beyond it)
+const maxPasswordLength = 72
+
+// PasswordPolicy defines the rules new passwords must follow
+type PasswordPolicy struct {
+	MinLength int
+	// Character classes which must appear on the password (lower, upper, digit and symbol)
+	RequiredClasses []string
+	// Passwords known from data breaches (no check is done if it's nil)
+	Breached BreachedPasswords
+}
+
+// PasswordPolicyError represents a password which doesn't follow a policy rule
+type PasswordPolicyError struct {
+	Rule    string
+	Message string
+}
+
+// Error returns the message describing the rule which failed
+func (err *PasswordPolicyError) Error() string {
+	return err.Message
+}
+
+// passwordPolicy is the policy applied to new passwords (set up when the application starts)
+var passwordPolicy = PasswordPolicy{MinLength: 8}
+
+// SetPasswordPolicy defines the policy applied to new passwords
+func SetPasswordPolicy(policy PasswordPolicy) {
+	passwordPolicy = policy
+}
+
+// IsValidPasswordClass checks if a character class can be required on passwords
+func IsValidPasswordClass(class string) bool {
+	switch class {
+	case ClassLower, ClassUpper, ClassDigit, ClassSymbol:
+		return true
+	}
+	return false
+}
+
+// ValidatePassword checks if a new password follows the password policy
+// Personal data (e.g. the username and email) must not be part of the password
+// If a rule fails, a *PasswordPolicyError is returned, any other error means the check itself failed
+func ValidatePassword(password string, personalData ...string) error {
+	// Checking the password length (in characters, but limited in bytes)
+	if length := len([]rune(password)); length < passwordPolicy.MinLength {
+		return &PasswordPolicyError{RuleMinLength, fmt.Sprintf("Password must have at least %d characters", passwordPolicy.MinLength)}
+	}
+	if len(password) > maxPasswordLength {
+		return &PasswordPolicyError{RuleMaxLength, fmt.Sprintf("Password must have at most %d bytes", maxPasswordLength)}
+	}
+
+	// Checking the required character classes
+	for _, class := range passwordPolicy.RequiredClasses {
+		if !hasCharacterClass(password, class) {
+			return &PasswordPolicyError{RuleClass, fmt.Sprintf("Password must contain at least one %s character", class)}
+		}
+	}
+
+	// Checking the personal data (emails are checked by their local part as well)
+	lowerPassword := strings.ToLower(password)
+	for _, data := range personalData {
+		data = strings.ToLower(strings.TrimSpace(data))
+		candidates := []string{data}
+		if at := strings.LastIndex(data, "@"); at > 0 {
+			candidates = append(candidates, data[:at])
+		}
+		for _, candidate := range candidates {
+			// Very short values would reject too many passwords
+			if len(candidate) >= 3 && strings.Contains(lowerPassword, candidate) {
+				return &PasswordPolicyError{RulePersonalData, "Password must not contain your username or email"}
+			}
+		}
+	}
+
+	// Checking if the password is known from data breaches
+	if passwordPolicy.Breached != nil {
+		breached, err := passwordPolicy.Breached.Contains(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			return &PasswordPolicyError{RuleBreached, "Password has appeared in a data breach, please choose another one"}
+		}
+	}
+
+	return nil
+}
+
+// hasCharacterClass checks if the password has at least one character from the class
+func hasCharacterClass(password, class string) bool {
+	for _, char := range password {
+		switch {
+		case class == ClassLower && unicode.IsLower(char),
+			class == ClassUpper && unicode.IsUpper(char),
+			class == ClassDigit && unicode.IsDigit(char),
+			class == ClassSymbol && !unicode.IsLetter(char) && !unicode.IsDigit(char) && !unicode.IsSpace(char):
+			return true
+		}
+	}
+	return false
+}
@@ -1,14 +1,67 @@
 package security
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"errors"
+)
+
+// Hasher represents a password hashing algorithm
+type Hasher interface {
+	// Hash creates a hash for the password, encoded with the algorithm parameters
+	Hash(pass string) (string, error)
+	// Recognizes checks if the hash was created by this algorithm
+	Recognizes(hashPass string) bool
+	// Check verifies if the password matches the hash
+	Check(pass, hashPass string) error
+	// IsCurrent checks if the hash was created with the hasher current parameters
+	IsCurrent(hashPass string) bool
+}
+
+// ErrUnsupportedHash is returned when a hash wasn't created by any supported algorithm
+var ErrUnsupportedHash = errors.New("Unsupported password hash format")
+
+// Defining the password hashers
+var (
+	// Hasher used to create new hashes (set up when the application starts)
+	currentHasher Hasher = NewBcryptHasher(0)
+	// Hashers recognized while checking passwords
+	supportedHashers = []Hasher{NewBcryptHasher(0), NewArgon2idHasher(Argon2idParams{})}
+)
+
+// SetHasher defines the hasher used to create new password hashes
+func SetHasher(hasher Hasher) {
+	currentHasher = hasher
+}
 
 // Hash will create a hash for the provided password
 func Hash(pass string) ([]byte, error) {
 	// Creating and returning the password hash
-	return bcrypt.GenerateFromPassword([]byte(pass), bcrypt.DefaultCost)
+	hashPass, err := currentHasher.Hash(pass)
+	return []byte(hashPass), err
 }
 
-// CheckPassword will verify if a password string matches a hash created for it
+// CheckPassword will verify if a password string matches a hash created for it, with any supported algorithm
 func CheckPassword(passString, hashPass string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashPass), []byte(passString))
+	hasher := findHasher(hashPass)
+	if hasher == nil {
+		return ErrUnsupportedHash
+	}
+	return hasher.Check(passString, hashPass)
+}
+
+// NeedsRehash checks if a hash must be created again, since its algorithm or parameters are out of date
+func NeedsRehash(hashPass string) bool {
+	return !currentHasher.Recognizes(hashPass) || !currentHasher.IsCurrent(hashPass)
+}
+
+// findHasher returns the hasher which created a hash (the current hasher is tried first)
+func findHasher(hashPass string) Hasher {
+	if currentHasher.Recognizes(hashPass) {
+		return currentHasher
+	}
+	for _, hasher := range supportedHashers {
+		if hasher.Recognizes(hashPass) {
+			return hasher
+		}
+	}
+	return nil
 }
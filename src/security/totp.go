@@ -0,0 +1,119 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Defining the TOTP parameters (RFC 6238 defaults, supported by most authenticator apps)
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	// Number of periods accepted before/after the current one, to tolerate clock drift
+	totpSkew = 1
+)
+
+// GenerateTOTPSecret generates a new random secret for TOTP, encoded as base32
+func GenerateTOTPSecret() (string, error) {
+	// Generating random data (160 bits, as recommended by RFC 4226)
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	// Encoding the secret, so it can be typed on authenticator apps
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret), nil
+}
+
+// TOTPURI returns the otpauth URI for a secret, which can be displayed as a QR code
+func TOTPURI(issuer, accountName, secret string) string {
+	// Setting the URI parameters
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+
+	// The label is formatted as "issuer:account"
+	label := url.PathEscape(issuer + ":" + accountName)
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, params.Encode())
+}
+
+// CheckTOTP verifies if a code is valid for the secret at the provided time
+// If it's valid, the time step of the code is returned, so it can't be used again
+func CheckTOTP(secret, code string, now time.Time) (int64, bool) {
+	// Decoding the secret
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	// Checking the code on the current time step and on the adjacent ones
+	code = strings.TrimSpace(code)
+	currentStep := now.Unix() / totpPeriod
+	for step := currentStep - totpSkew; step <= currentStep+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(generateTOTP(key, step)), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	// If no time step matches the code
+	return 0, false
+}
+
+// generateTOTP generates the code for a key on a specific time step (RFC 4226 HOTP)
+func generateTOTP(key []byte, step int64) string {
+	// Calculating the HMAC of the time step
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	// Formatting the code with the number of digits
+	modulo := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%modulo)
+}
+
+// GenerateRecoveryCodes generates random one-time codes, used when the authenticator app is not available
+func GenerateRecoveryCodes(quantity int) ([]string, error) {
+	codes := make([]string, quantity)
+	for i := range codes {
+		// Generating random data (50 bits per code)
+		data := make([]byte, 10)
+		if _, err := rand.Read(data); err != nil {
+			return nil, err
+		}
+
+		// Formatting the code as two groups of lowercase characters (e.g. "abcde-fghij")
+		code := strings.ToLower(base32.StdEncoding.EncodeToString(data))[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes, nil
+}
+
+// NormalizeRecoveryCode formats a recovery code typed by the user, so it can be compared with the stored ones
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) == 10 && !strings.Contains(code, "-") {
+		code = code[:5] + "-" + code[5:]
+	}
+	return code
+}
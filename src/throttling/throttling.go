@@ -0,0 +1,138 @@
+package throttling
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time (it can be replaced, e.g. to test the throttling without waiting)
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the clock which returns the actual current time
+type SystemClock struct{}
+
+// Now returns the current time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Policy defines how failed attempts are throttled
+type Policy struct {
+	// Number of failures allowed before attempts start being delayed
+	FreeAttempts int
+	// Delay after the first delayed failure, doubled for each following one (up to MaxDelay)
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Number of failures which lock further attempts, and for how long
+	LockoutAttempts int
+	LockoutDuration time.Duration
+	// Failures older than this are forgotten
+	Window time.Duration
+}
+
+// entry represents the failed attempts registered for a key (e.g. an account or an IP address)
+type entry struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+	locked       bool
+}
+
+// Throttle tracks failed attempts by key, delaying (exponential backoff) and locking further attempts
+// It's safe for concurrent use, and keeps the data on the application memory
+type Throttle struct {
+	policy  Policy
+	clock   Clock
+	mutex   sync.Mutex
+	entries map[string]*entry
+	cleaned time.Time
+}
+
+// NewThrottle instantiates/initializes a throttle with the provided policy and clock
+func NewThrottle(policy Policy, clock Clock) *Throttle {
+	return &Throttle{policy: policy, clock: clock, entries: make(map[string]*entry)}
+}
+
+// Check returns how long the caller must wait before attempting again with the key (zero if it's allowed)
+// It also returns whether the key is locked (instead of just delayed)
+func (throttle *Throttle) Check(key string) (time.Duration, bool) {
+	throttle.mutex.Lock()
+	defer throttle.mutex.Unlock()
+
+	// Checking if the key is blocked
+	entry, ok := throttle.entries[key]
+	if !ok {
+		return 0, false
+	}
+	now := throttle.clock.Now()
+	if now.Before(entry.blockedUntil) {
+		return entry.blockedUntil.Sub(now), entry.locked
+	}
+	return 0, false
+}
+
+// RegisterFailure registers a failed attempt with the key
+// It returns how long the caller must wait before attempting again, and whether the key is now locked
+func (throttle *Throttle) RegisterFailure(key string) (time.Duration, bool) {
+	throttle.mutex.Lock()
+	defer throttle.mutex.Unlock()
+
+	now := throttle.clock.Now()
+	throttle.removeExpired(now)
+
+	// Getting the key entry (old failures, and failures before an expired lockout, are forgotten)
+	current, ok := throttle.entries[key]
+	if !ok || now.Sub(current.lastFailure) > throttle.policy.Window || (current.locked && !now.Before(current.blockedUntil)) {
+		current = &entry{}
+		throttle.entries[key] = current
+	}
+	current.failures++
+	current.lastFailure = now
+
+	// Locking the key, if the number of failures was reached
+	if throttle.policy.LockoutAttempts > 0 && current.failures >= throttle.policy.LockoutAttempts {
+		current.blockedUntil = now.Add(throttle.policy.LockoutDuration)
+		current.locked = true
+		return throttle.policy.LockoutDuration, true
+	}
+
+	// Delaying the next attempt, doubling the delay for each failure after the free ones
+	if current.failures > throttle.policy.FreeAttempts {
+		delay := throttle.policy.BaseDelay
+		for i := throttle.policy.FreeAttempts + 1; i < current.failures && delay < throttle.policy.MaxDelay; i++ {
+			delay *= 2
+		}
+		if delay > throttle.policy.MaxDelay {
+			delay = throttle.policy.MaxDelay
+		}
+		current.blockedUntil = now.Add(delay)
+		return delay, false
+	}
+
+	return 0, false
+}
+
+// Reset forgets the failed attempts registered with the key (e.g. after a successful attempt)
+func (throttle *Throttle) Reset(key string) {
+	throttle.mutex.Lock()
+	defer throttle.mutex.Unlock()
+
+	delete(throttle.entries, key)
+}
+
+// removeExpired forgets entries which are no longer blocked and whose failures are out of the window
+// It runs at most once a minute, and the mutex must be locked by the caller
+func (throttle *Throttle) removeExpired(now time.Time) {
+	if now.Sub(throttle.cleaned) < time.Minute {
+		return
+	}
+	throttle.cleaned = now
+
+	for key, entry := range throttle.entries {
+		if now.After(entry.blockedUntil) && now.Sub(entry.lastFailure) > throttle.policy.Window {
+			delete(throttle.entries, key)
+		}
+	}
+}
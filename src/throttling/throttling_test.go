@@ -0,0 +1,152 @@
+package throttling
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock which only moves when the test advances it
+type fakeClock struct {
+	now time.Time
+}
+
+func (clock *fakeClock) Now() time.Time {
+	return clock.now
+}
+
+// step is an action on the throttle (after advancing the clock), with the expected wait and lock
+type step struct {
+	advance time.Duration
+	action  string // "fail", "check" or "reset"
+	wait    time.Duration
+	locked  bool
+}
+
+func TestThrottle(t *testing.T) {
+	policy := Policy{
+		FreeAttempts:    2,
+		BaseDelay:       time.Second,
+		MaxDelay:        4 * time.Second,
+		LockoutAttempts: 7,
+		LockoutDuration: time.Minute,
+		Window:          10 * time.Minute,
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "free attempts are not delayed",
+			steps: []step{
+				{action: "fail"},
+				{action: "fail"},
+				{action: "check"},
+			},
+		},
+		{
+			name: "delay doubles after the free attempts, up to the maximum",
+			steps: []step{
+				{action: "fail"},
+				{action: "fail"},
+				{action: "fail", wait: time.Second},
+				{advance: time.Second, action: "fail", wait: 2 * time.Second},
+				{advance: 2 * time.Second, action: "fail", wait: 4 * time.Second},
+				{action: "check", wait: 4 * time.Second},
+				{advance: 3 * time.Second, action: "check", wait: time.Second},
+				{advance: time.Second, action: "check"},
+			},
+		},
+		{
+			name: "maximum delay is not exceeded",
+			steps: []step{
+				{action: "fail"},
+				{action: "fail"},
+				{action: "fail", wait: time.Second},
+				{action: "fail", wait: 2 * time.Second},
+				{action: "fail", wait: 4 * time.Second},
+				{action: "fail", wait: 4 * time.Second},
+			},
+		},
+		{
+			name: "lockout after the lockout attempts, until its duration ends",
+			steps: []step{
+				{action: "fail"},
+				{action: "fail"},
+				{action: "fail", wait: time.Second},
+				{action: "fail", wait: 2 * time.Second},
+				{action: "fail", wait: 4 * time.Second},
+				{action: "fail", wait: 4 * time.Second},
+				{action: "fail", wait: time.Minute, locked: true},
+				{advance: 30 * time.Second, action: "check", wait: 30 * time.Second, locked: true},
+				{advance: 30 * time.Second, action: "check"},
+				// Failures before an expired lockout are forgotten
+				{action: "fail"},
+				{action: "fail"},
+				{action: "fail", wait: time.Second},
+			},
+		},
+		{
+			name: "failures out of the window are forgotten",
+			steps: []step{
+				{action: "fail"},
+				{action: "fail"},
+				{action: "fail", wait: time.Second},
+				{advance: 11 * time.Minute, action: "check"},
+				{action: "fail"},
+				{action: "fail"},
+				{action: "fail", wait: time.Second},
+			},
+		},
+		{
+			name: "reset forgets the failures",
+			steps: []step{
+				{action: "fail"},
+				{action: "fail"},
+				{action: "fail", wait: time.Second},
+				{action: "reset"},
+				{action: "check"},
+				{action: "fail"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+			throttle := NewThrottle(policy, clock)
+
+			for i, step := range test.steps {
+				clock.now = clock.now.Add(step.advance)
+
+				var wait time.Duration
+				var locked bool
+				switch step.action {
+				case "fail":
+					wait, locked = throttle.RegisterFailure("key")
+				case "check":
+					wait, locked = throttle.Check("key")
+				case "reset":
+					throttle.Reset("key")
+				}
+
+				if wait != step.wait || locked != step.locked {
+					t.Errorf("step %d (%s): got wait %v and locked %v, want wait %v and locked %v",
+						i, step.action, wait, locked, step.wait, step.locked)
+				}
+			}
+		})
+	}
+}
+
+func TestThrottleKeysAreIndependent(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	throttle := NewThrottle(Policy{BaseDelay: time.Second, MaxDelay: time.Second, Window: time.Minute}, clock)
+
+	if wait, _ := throttle.RegisterFailure("a"); wait != time.Second {
+		t.Fatalf("got wait %v for the failing key, want %v", wait, time.Second)
+	}
+	if wait, _ := throttle.Check("b"); wait != 0 {
+		t.Fatalf("got wait %v for another key, want 0", wait)
+	}
+}